@@ -7,7 +7,9 @@ package did
 
 import (
 	"bytes"
-	"crypto/ed25519"
+	"context"
+	"crypto"
+	"crypto/rand"
 	"crypto/tls"
 	"encoding/json"
 	"errors"
@@ -15,13 +17,16 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"strings"
+	"time"
 
 	docdid "github.com/hyperledger/aries-framework-go/pkg/doc/did"
 	log "github.com/sirupsen/logrus"
 	"github.com/trustbloc/sidetree-core-go/pkg/jws"
+	"github.com/trustbloc/sidetree-core-go/pkg/patch"
 	"github.com/trustbloc/sidetree-core-go/pkg/restapi/helper"
-	"github.com/trustbloc/sidetree-core-go/pkg/util/pubkey"
 
+	"github.com/trustbloc/trustbloc-did-method/pkg/internal/common/resilient"
 	"github.com/trustbloc/trustbloc-did-method/pkg/vdri/trustbloc/config/httpconfig"
 	"github.com/trustbloc/trustbloc-did-method/pkg/vdri/trustbloc/discovery/staticdiscovery"
 	"github.com/trustbloc/trustbloc-did-method/pkg/vdri/trustbloc/endpoint"
@@ -30,13 +35,13 @@ import (
 )
 
 const (
-	sha2_256            = 18
-	recoveryRevealValue = "recoveryOTP"
-	updateRevealValue   = "updateOTP"
+	sha2_256          = 18
+	revealValueLength = 32
+	defaultTimeout    = 10 * time.Second
 )
 
 type endpointService interface {
-	GetEndpoints(domain string) ([]*models.Endpoint, error)
+	GetEndpointsWithContext(ctx context.Context, domain string) ([]*models.Endpoint, error)
 }
 
 // Client for did bloc
@@ -45,6 +50,11 @@ type Client struct {
 	client          *http.Client
 	tlsConfig       *tls.Config
 	authToken       string
+	signer          Signer
+	createAttempts  int
+	timeout         time.Duration
+	transportOpts   []resilient.Option
+	configOpts      []httpconfig.Option
 }
 
 type didResolution struct {
@@ -56,15 +66,17 @@ type didResolution struct {
 
 // New return did bloc client
 func New(opts ...Option) *Client {
-	c := &Client{client: &http.Client{}}
+	c := &Client{client: &http.Client{}, timeout: defaultTimeout}
 
 	// Apply options
 	for _, opt := range opts {
 		opt(c)
 	}
 
-	c.client.Transport = &http.Transport{TLSClientConfig: c.tlsConfig}
-	configService := httpconfig.NewService(httpconfig.WithTLSConfig(c.tlsConfig))
+	c.client.Transport = resilient.NewTransport(&http.Transport{TLSClientConfig: c.tlsConfig}, c.transportOpts...)
+	c.client.Timeout = c.timeout
+	configService := httpconfig.NewService(append([]httpconfig.Option{httpconfig.WithTLSConfig(c.tlsConfig),
+		httpconfig.WithTimeout(c.timeout)}, c.configOpts...)...)
 	c.endpointService = endpoint.NewService(
 		staticdiscovery.NewService(configService),
 		staticselection.NewService(configService))
@@ -72,8 +84,62 @@ func New(opts ...Option) *Client {
 	return c
 }
 
-// CreateDID create did doc
-func (c *Client) CreateDID(domain string, opts ...CreateDIDOption) (*docdid.Doc, error) {
+// OperationResult holds the did document returned by the sidetree node and the reveal values
+// that have to be presented by the next operations on the did
+type OperationResult struct {
+	// DIDDoc is nil when the sidetree node doesn't echo the document
+	DIDDoc *docdid.Doc
+	// UpdateRevealValue is the reveal value for the next update operation
+	UpdateRevealValue []byte
+	// RecoveryRevealValue is the reveal value for the next recover or deactivate operation.
+	// It is nil for update operations, which don't change the recovery commitment.
+	RecoveryRevealValue []byte
+}
+
+// CreateDID create did doc.
+// The reveal values for the next update and recovery are generated unless they are supplied as options,
+// and are returned in the result; they have to be kept secret by the caller.
+func (c *Client) CreateDID(domain string, opts ...CreateDIDOption) (*OperationResult, error) {
+	return c.CreateDIDWithContext(context.Background(), domain, opts...)
+}
+
+// CreateDIDWithContext create did doc, the discovery and sidetree requests are cancelled with the context
+func (c *Client) CreateDIDWithContext(ctx context.Context, domain string,
+	opts ...CreateDIDOption) (*OperationResult, error) {
+	createDIDOpts, err := newCreateDIDOpts(domain, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints, err := c.getEndpoints(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := c.buildSideTreeRequest(createDIDOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sidetree request: %w", err)
+	}
+
+	responseBytes, err := c.sendCreateRequest(ctx, req, endpoints)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send create sidetree request: %w", err)
+	}
+
+	didDoc, err := parseResponseDocument(responseBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OperationResult{
+		DIDDoc:              didDoc,
+		UpdateRevealValue:   createDIDOpts.nextUpdateRevealValue,
+		RecoveryRevealValue: createDIDOpts.nextRecoveryRevealValue,
+	}, nil
+}
+
+// newCreateDIDOpts applies the create options and generates the reveal values that are not supplied
+func newCreateDIDOpts(domain string, opts []CreateDIDOption) (*CreateDIDOpts, error) {
 	if domain == "" {
 		return nil, errors.New("domain is empty")
 	}
@@ -84,52 +150,273 @@ func (c *Client) CreateDID(domain string, opts ...CreateDIDOption) (*docdid.Doc,
 		opt(createDIDOpts)
 	}
 
-	endpoints, err := c.endpointService.GetEndpoints(domain)
+	var err error
+
+	createDIDOpts.nextRecoveryRevealValue, err = revealValueOrNew(createDIDOpts.nextRecoveryRevealValue)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get endpoints: %w", err)
+		return nil, err
 	}
 
-	if len(endpoints) == 0 {
-		return nil, errors.New("list of endpoints is empty")
+	createDIDOpts.nextUpdateRevealValue, err = revealValueOrNew(createDIDOpts.nextUpdateRevealValue)
+	if err != nil {
+		return nil, err
 	}
 
-	req, err := c.buildSideTreeRequest(createDIDOpts)
+	return createDIDOpts, nil
+}
+
+// UpdateDID sends a signed sidetree update operation for the given did, built from the patch options.
+// The returned document is nil when the sidetree node doesn't echo the updated document
+// (the update only becomes visible once the operation is anchored).
+func (c *Client) UpdateDID(did string, opts ...UpdateDIDOption) (*OperationResult, error) {
+	return c.UpdateDIDWithContext(context.Background(), did, opts...)
+}
+
+// UpdateDIDWithContext sends a signed sidetree update operation for the given did,
+// the discovery and sidetree requests are cancelled with the context
+func (c *Client) UpdateDIDWithContext(ctx context.Context, did string,
+	opts ...UpdateDIDOption) (*OperationResult, error) {
+	updateDIDOpts := &UpdateDIDOpts{}
+	// Apply options
+	for _, opt := range opts {
+		opt(updateDIDOpts)
+	}
+
+	if len(updateDIDOpts.updateRevealValue) == 0 {
+		return nil, errors.New("update reveal value is required")
+	}
+
+	var err error
+
+	updateDIDOpts.nextUpdateRevealValue, err = revealValueOrNew(updateDIDOpts.nextUpdateRevealValue)
+	if err != nil {
+		return nil, err
+	}
+
+	didDoc, err := c.sendOperation(ctx, did, "update", func(didSuffix string) ([]byte, error) {
+		return c.buildUpdateRequest(didSuffix, updateDIDOpts)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &OperationResult{DIDDoc: didDoc, UpdateRevealValue: updateDIDOpts.nextUpdateRevealValue}, nil
+}
+
+// RecoverDID sends a signed sidetree recover operation for the given did, replacing its document with the
+// public keys and services from the options. The operation is signed with the current recovery private key.
+// The returned document is nil when the sidetree node doesn't echo the recovered document.
+func (c *Client) RecoverDID(did string, opts ...RecoverDIDOption) (*OperationResult, error) {
+	return c.RecoverDIDWithContext(context.Background(), did, opts...)
+}
+
+// RecoverDIDWithContext sends a signed sidetree recover operation for the given did,
+// the discovery and sidetree requests are cancelled with the context
+func (c *Client) RecoverDIDWithContext(ctx context.Context, did string,
+	opts ...RecoverDIDOption) (*OperationResult, error) {
+	recoverDIDOpts := &RecoverDIDOpts{}
+	// Apply options
+	for _, opt := range opts {
+		opt(recoverDIDOpts)
+	}
+
+	if len(recoverDIDOpts.recoveryRevealValue) == 0 {
+		return nil, errors.New("recovery reveal value is required")
+	}
+
+	var err error
+
+	recoverDIDOpts.nextRecoveryRevealValue, err = revealValueOrNew(recoverDIDOpts.nextRecoveryRevealValue)
+	if err != nil {
+		return nil, err
+	}
+
+	recoverDIDOpts.nextUpdateRevealValue, err = revealValueOrNew(recoverDIDOpts.nextUpdateRevealValue)
+	if err != nil {
+		return nil, err
+	}
+
+	didDoc, err := c.sendOperation(ctx, did, "recover", func(didSuffix string) ([]byte, error) {
+		return c.buildRecoverRequest(didSuffix, recoverDIDOpts)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &OperationResult{
+		DIDDoc:              didDoc,
+		UpdateRevealValue:   recoverDIDOpts.nextUpdateRevealValue,
+		RecoveryRevealValue: recoverDIDOpts.nextRecoveryRevealValue,
+	}, nil
+}
+
+// DeactivateDID sends a signed sidetree deactivate operation for the given did.
+// The operation is signed with the current recovery private key.
+func (c *Client) DeactivateDID(did string, opts ...DeactivateDIDOption) error {
+	return c.DeactivateDIDWithContext(context.Background(), did, opts...)
+}
+
+// DeactivateDIDWithContext sends a signed sidetree deactivate operation for the given did,
+// the discovery and sidetree requests are cancelled with the context
+func (c *Client) DeactivateDIDWithContext(ctx context.Context, did string, opts ...DeactivateDIDOption) error {
+	deactivateDIDOpts := &DeactivateDIDOpts{}
+	// Apply options
+	for _, opt := range opts {
+		opt(deactivateDIDOpts)
+	}
+
+	if len(deactivateDIDOpts.recoveryRevealValue) == 0 {
+		return errors.New("recovery reveal value is required")
+	}
+
+	_, err := c.sendOperation(ctx, did, "deactivate", func(didSuffix string) ([]byte, error) {
+		return c.buildDeactivateRequest(didSuffix, deactivateDIDOpts)
+	})
+
+	return err
+}
+
+// sendOperation builds a sidetree operation for an existing did and sends it to one of the consortium endpoints
+func (c *Client) sendOperation(ctx context.Context, did, operation string,
+	buildRequest func(didSuffix string) ([]byte, error)) (*docdid.Doc, error) {
+	parsed, err := ParseDID(did)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints, err := c.getEndpoints(ctx, parsed.Domain)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := buildRequest(parsed.Suffix)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build sidetree request: %w", err)
 	}
 
-	resDoc, err := c.sendCreateRequest(req, endpoints[0].URL)
+	responseBytes, err := c.sendRequest(ctx, req, endpoints[0].URL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send create sidetree request: %w", err)
+		return nil, fmt.Errorf("failed to send %s sidetree request: %w", operation, err)
+	}
+
+	if isEmptyResponse(responseBytes) {
+		return nil, nil
 	}
 
-	return resDoc, nil
+	return parseResponseDocument(responseBytes)
 }
 
-// buildSideTreeRequest request builder for sidetree public DID creation
-func (c *Client) buildSideTreeRequest(createDIDOpts *CreateDIDOpts) ([]byte, error) {
-	publicKeys := createDIDOpts.publicKeys
+// sendCreateRequest sends the create request to the endpoints in failover order, moving on to the next
+// endpoint on transport errors and 5xx responses until the attempt budget is spent
+func (c *Client) sendCreateRequest(ctx context.Context, req []byte, endpoints []*models.Endpoint) ([]byte, error) {
+	candidates := failoverOrder(endpoints)
+	if c.createAttempts > 0 && c.createAttempts < len(candidates) {
+		candidates = candidates[:c.createAttempts]
+	}
 
-	doc := &Doc{
-		PublicKey: publicKeys,
-		Service:   createDIDOpts.services,
+	var failures []string
+
+	for _, ep := range candidates {
+		responseBytes, err := c.sendRequest(ctx, req, ep.URL)
+		if err == nil {
+			return responseBytes, nil
+		}
+
+		failures = append(failures, fmt.Sprintf("endpoint %s: %s", ep.URL, err))
+
+		if !isRetryable(err) || ctx.Err() != nil {
+			break
+		}
+
+		log.Warnf("create request to endpoint %s failed, trying next endpoint: %s", ep.URL, err)
 	}
 
-	docBytes, err := doc.JSONBytes()
+	return nil, fmt.Errorf("all attempts failed: [%s]", strings.Join(failures, "; "))
+}
+
+// failoverOrder interleaves the endpoints by stakeholder domain, so that consecutive attempts go to
+// different stakeholders while the order of the endpoints of each stakeholder is kept
+func failoverOrder(endpoints []*models.Endpoint) []*models.Endpoint {
+	var domains []string
+
+	byDomain := make(map[string][]*models.Endpoint)
+
+	for _, ep := range endpoints {
+		if _, ok := byDomain[ep.Domain]; !ok {
+			domains = append(domains, ep.Domain)
+		}
+
+		byDomain[ep.Domain] = append(byDomain[ep.Domain], ep)
+	}
+
+	ordered := make([]*models.Endpoint, 0, len(endpoints))
+
+	for len(ordered) < len(endpoints) {
+		for _, domain := range domains {
+			if len(byDomain[domain]) == 0 {
+				continue
+			}
+
+			ordered = append(ordered, byDomain[domain][0])
+			byDomain[domain] = byDomain[domain][1:]
+		}
+	}
+
+	return ordered
+}
+
+// isRetryable returns false for errors reported by the sidetree node with a non 5xx status,
+// which would fail the same way on any other endpoint
+func isRetryable(err error) bool {
+	var respErr *responseError
+	if errors.As(err, &respErr) {
+		return respErr.statusCode >= http.StatusInternalServerError
+	}
+
+	return true
+}
+
+// revealValueOrNew returns the given reveal value, or a new random one if it is empty
+func revealValueOrNew(revealValue []byte) ([]byte, error) {
+	if len(revealValue) != 0 {
+		return revealValue, nil
+	}
+
+	newRevealValue := make([]byte, revealValueLength)
+
+	if _, err := rand.Read(newRevealValue); err != nil {
+		return nil, fmt.Errorf("failed to generate reveal value: %w", err)
+	}
+
+	return newRevealValue, nil
+}
+
+func (c *Client) getEndpoints(ctx context.Context, domain string) ([]*models.Endpoint, error) {
+	endpoints, err := c.endpointService.GetEndpointsWithContext(ctx, domain)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get document bytes : %s", err)
+		return nil, fmt.Errorf("failed to get endpoints: %w", err)
 	}
 
-	recoveryKey, err := c.getRecoveryKey(publicKeys)
+	if len(endpoints) == 0 {
+		return nil, errors.New("list of endpoints is empty")
+	}
+
+	return endpoints, nil
+}
+
+// buildSideTreeRequest request builder for sidetree public DID creation
+func (c *Client) buildSideTreeRequest(createDIDOpts *CreateDIDOpts) ([]byte, error) {
+	docBytes, recoveryKey, err := c.buildDocument(createDIDOpts.publicKeys, createDIDOpts.services,
+		createDIDOpts.recoveryKeyID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get recovery key : %s", err)
+		return nil, err
 	}
 
 	req, err := helper.NewCreateRequest(&helper.CreateRequestInfo{
 		OpaqueDocument:          string(docBytes),
 		RecoveryKey:             recoveryKey,
-		NextRecoveryRevealValue: []byte(recoveryRevealValue),
-		NextUpdateRevealValue:   []byte(updateRevealValue),
+		NextRecoveryRevealValue: createDIDOpts.nextRecoveryRevealValue,
+		NextUpdateRevealValue:   createDIDOpts.nextUpdateRevealValue,
 		MultihashCode:           sha2_256,
 	})
 	if err != nil {
@@ -139,22 +426,233 @@ func (c *Client) buildSideTreeRequest(createDIDOpts *CreateDIDOpts) ([]byte, err
 	return req, nil
 }
 
-func (c *Client) getRecoveryKey(publicKeys []PublicKey) (*jws.JWK, error) {
+// buildRecoverRequest request builder for sidetree recover operation
+func (c *Client) buildRecoverRequest(didSuffix string, recoverDIDOpts *RecoverDIDOpts) ([]byte, error) {
+	docBytes, recoveryKey, err := c.buildDocument(recoverDIDOpts.publicKeys, recoverDIDOpts.services,
+		recoverDIDOpts.newRecoveryKeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := c.operationSigner(recoverDIDOpts.signingKey, recoverDIDOpts.signerKeyID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := helper.NewRecoverRequest(&helper.RecoverRequestInfo{
+		DidSuffix:               didSuffix,
+		RecoveryRevealValue:     recoverDIDOpts.recoveryRevealValue,
+		RecoveryKey:             recoveryKey,
+		OpaqueDocument:          string(docBytes),
+		NextRecoveryRevealValue: recoverDIDOpts.nextRecoveryRevealValue,
+		NextUpdateRevealValue:   recoverDIDOpts.nextUpdateRevealValue,
+		MultihashCode:           sha2_256,
+		Signer:                  signer,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sidetree request: %w", err)
+	}
+
+	return req, nil
+}
+
+// buildDeactivateRequest request builder for sidetree deactivate operation
+func (c *Client) buildDeactivateRequest(didSuffix string, deactivateDIDOpts *DeactivateDIDOpts) ([]byte, error) {
+	signer, err := c.operationSigner(deactivateDIDOpts.signingKey, deactivateDIDOpts.signerKeyID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := helper.NewDeactivateRequest(&helper.DeactivateRequestInfo{
+		DidSuffix:           didSuffix,
+		RecoveryRevealValue: deactivateDIDOpts.recoveryRevealValue,
+		Signer:              signer,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sidetree request: %w", err)
+	}
+
+	return req, nil
+}
+
+// buildDocument returns the opaque document and the recovery key for the given public keys and services.
+// The recovery key is taken from the signer when recoveryKeyID is set.
+func (c *Client) buildDocument(publicKeys []PublicKey, services []docdid.Service,
+	recoveryKeyID string) ([]byte, *jws.JWK, error) {
+	doc := &Doc{
+		PublicKey: publicKeys,
+		Service:   services,
+	}
+
+	docBytes, err := doc.JSONBytes()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get document bytes : %s", err)
+	}
+
+	recoveryKey, err := c.getRecoveryKey(publicKeys, recoveryKeyID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get recovery key : %s", err)
+	}
+
+	return docBytes, recoveryKey, nil
+}
+
+func (c *Client) getRecoveryKey(publicKeys []PublicKey, recoveryKeyID string) (*jws.JWK, error) {
+	if recoveryKeyID != "" {
+		if c.signer == nil {
+			return nil, errors.New("signer is not configured")
+		}
+
+		return c.signer.PublicKeyJWK(recoveryKeyID)
+	}
+
 	for _, v := range publicKeys {
 		if v.Recovery {
-			if v.Encoding != PublicKeyEncodingJwk {
-				return nil, fmt.Errorf("public key encoding not supported: %s", v.Encoding)
+			recoveryKey := v
+			if recoveryKey.KeyType == "" {
+				recoveryKey.KeyType = Ed25519KeyType
+			}
+
+			if recoveryKey.KeyType == X25519KeyType {
+				return nil, fmt.Errorf("%s key can't be used as recovery key", X25519KeyType)
+			}
+
+			// the recovery key is always sent as jwk, its encoding follows the rules of the document keys
+			if err := validateEncoding(&recoveryKey); err != nil {
+				return nil, err
 			}
 
-			return pubkey.GetPublicKeyJWK(ed25519.PublicKey(v.Value))
+			return publicKeyJWK(recoveryKey.KeyType, recoveryKey.Value)
 		}
 	}
 
 	return nil, fmt.Errorf("recovery key not found")
 }
 
-func (c *Client) sendCreateRequest(req []byte, endpointURL string) (*docdid.Doc, error) {
-	httpReq, err := http.NewRequest(http.MethodPost, endpointURL+"/operations", bytes.NewReader(req))
+// buildUpdateRequest request builder for sidetree update operation
+func (c *Client) buildUpdateRequest(didSuffix string, updateDIDOpts *UpdateDIDOpts) ([]byte, error) {
+	updatePatch, err := buildUpdatePatch(updateDIDOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := c.operationSigner(updateDIDOpts.signingKey, updateDIDOpts.signerKeyID,
+		updateDIDOpts.signingKeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := helper.NewUpdateRequest(&helper.UpdateRequestInfo{
+		DidSuffix:             didSuffix,
+		Patch:                 updatePatch,
+		UpdateRevealValue:     updateDIDOpts.updateRevealValue,
+		NextUpdateRevealValue: updateDIDOpts.nextUpdateRevealValue,
+		MultihashCode:         sha2_256,
+		Signer:                signer,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sidetree request: %w", err)
+	}
+
+	return req, nil
+}
+
+// buildUpdatePatch builds the sidetree patch for an update operation.
+// Sidetree update operations carry a single patch, so only one kind of change is accepted per update.
+func buildUpdatePatch(updateDIDOpts *UpdateDIDOpts) (patch.Patch, error) {
+	var patches []patch.Patch
+
+	if len(updateDIDOpts.addPublicKeys) != 0 {
+		rawPublicKeys, err := populateRawPublicKeys(updateDIDOpts.addPublicKeys)
+		if err != nil {
+			return nil, err
+		}
+
+		p, err := newPatch(patch.NewAddPublicKeysPatch, rawPublicKeys)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create add public keys patch: %w", err)
+		}
+
+		patches = append(patches, p)
+	}
+
+	if len(updateDIDOpts.removePublicKeys) != 0 {
+		p, err := newPatch(patch.NewRemovePublicKeysPatch, updateDIDOpts.removePublicKeys)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create remove public keys patch: %w", err)
+		}
+
+		patches = append(patches, p)
+	}
+
+	if len(updateDIDOpts.addServices) != 0 {
+		p, err := newPatch(patch.NewAddServiceEndpointsPatch, populateRawServices(updateDIDOpts.addServices))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create add services patch: %w", err)
+		}
+
+		patches = append(patches, p)
+	}
+
+	if len(updateDIDOpts.removeServices) != 0 {
+		p, err := newPatch(patch.NewRemoveServiceEndpointsPatch, updateDIDOpts.removeServices)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create remove services patch: %w", err)
+		}
+
+		patches = append(patches, p)
+	}
+
+	switch len(patches) {
+	case 0:
+		return nil, errors.New("no update patch provided")
+	case 1:
+		return patches[0], nil
+	default:
+		return nil, errors.New("only one patch action is supported per update operation")
+	}
+}
+
+func newPatch(newFunc func(string) (patch.Patch, error), value interface{}) (patch.Patch, error) {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return newFunc(string(valueBytes))
+}
+
+// operationSigner returns a sidetree request signer for either the given private key, or the key
+// identified by signerKeyID in the client signer. kid is set as the JWS key ID header when not empty.
+func (c *Client) operationSigner(privateKey crypto.PrivateKey, signerKeyID, kid string) (helper.Signer, error) {
+	signer := c.signer
+
+	switch {
+	case privateKey != nil:
+		inMemorySigner := NewInMemorySigner()
+
+		if err := inMemorySigner.AddKey(signerKeyID, privateKey); err != nil {
+			return nil, fmt.Errorf("invalid signing key: %w", err)
+		}
+
+		signer = inMemorySigner
+	case signerKeyID == "":
+		return nil, errors.New("signing key is required")
+	case signer == nil:
+		return nil, errors.New("signer is not configured")
+	}
+
+	alg, err := signer.Algorithm(signerKeyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get signing key algorithm: %w", err)
+	}
+
+	return &sidetreeSigner{signer: signer, keyID: signerKeyID, alg: alg, kid: kid}, nil
+}
+
+func (c *Client) sendRequest(ctx context.Context, req []byte, endpointURL string) ([]byte, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpointURL+"/operations",
+		bytes.NewReader(req))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create http request: %w", err)
 	}
@@ -178,10 +676,30 @@ func (c *Client) sendCreateRequest(req []byte, endpointURL string) (*docdid.Doc,
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("got unexpected response from %s status '%d' body %s",
-			endpointURL, resp.StatusCode, responseBytes)
+		return nil, &responseError{endpointURL: endpointURL, statusCode: resp.StatusCode, body: responseBytes}
 	}
 
+	return responseBytes, nil
+}
+
+// responseError is returned when the sidetree node responds with an unexpected status
+type responseError struct {
+	endpointURL string
+	statusCode  int
+	body        []byte
+}
+
+func (e *responseError) Error() string {
+	return fmt.Sprintf("got unexpected response from %s status '%d' body %s", e.endpointURL, e.statusCode, e.body)
+}
+
+func isEmptyResponse(responseBytes []byte) bool {
+	trimmed := bytes.TrimSpace(responseBytes)
+
+	return len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null"))
+}
+
+func parseResponseDocument(responseBytes []byte) (*docdid.Doc, error) {
 	var r didResolution
 	if errUnmarshal := json.Unmarshal(responseBytes, &r); errUnmarshal != nil {
 		return nil, fmt.Errorf("unmarshal data return from sidtree %w", errUnmarshal)
@@ -193,7 +711,7 @@ func (c *Client) sendCreateRequest(req []byte, endpointURL string) (*docdid.Doc,
 		didDocBytes = r.DIDDocument
 	}
 
-	didDoc, err := docdid.ParseDocument(didDocBytes)
+	didDoc, err := ParseDocument(didDocBytes)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse public DID document: %s", err)
 	}
@@ -225,10 +743,54 @@ func WithAuthToken(authToken string) Option {
 	}
 }
 
+// WithCreateAttempts set the maximum number of endpoints tried by CreateDID before giving up.
+// By default all the discovered endpoints are tried.
+func WithCreateAttempts(attempts int) Option {
+	return func(opts *Client) {
+		opts.createAttempts = attempts
+	}
+}
+
+// WithTimeout set the timeout of the sidetree and config requests (10 seconds by default)
+func WithTimeout(timeout time.Duration) Option {
+	return func(opts *Client) {
+		opts.timeout = timeout
+	}
+}
+
+// WithRetry set the number of times the config requests failing with transport errors, 429 or 5xx responses
+// are retried, with a backoff doubling from the initial backoff up to the max backoff.
+// The sidetree operation requests aren't idempotent and are never retried.
+func WithRetry(maxRetries int, initialBackoff, maxBackoff time.Duration) Option {
+	return func(opts *Client) {
+		opts.transportOpts = append(opts.transportOpts, resilient.WithRetry(maxRetries, initialBackoff, maxBackoff))
+		opts.configOpts = append(opts.configOpts, httpconfig.WithRetry(maxRetries, initialBackoff, maxBackoff))
+	}
+}
+
+// WithCircuitBreaker set the number of consecutive failures after which a sidetree or config endpoint is
+// skipped for the open timeout. CreateDID moves on to the next endpoint when an endpoint is skipped.
+func WithCircuitBreaker(failureThreshold int, openTimeout time.Duration) Option {
+	return func(opts *Client) {
+		opts.transportOpts = append(opts.transportOpts, resilient.WithCircuitBreaker(failureThreshold, openTimeout))
+		opts.configOpts = append(opts.configOpts, httpconfig.WithCircuitBreaker(failureThreshold, openTimeout))
+	}
+}
+
+// WithSigner set the signer holding the private keys referenced by the *SigningKeyID and *RecoveryKeyID options
+func WithSigner(signer Signer) Option {
+	return func(opts *Client) {
+		opts.signer = signer
+	}
+}
+
 // CreateDIDOpts create did opts
 type CreateDIDOpts struct {
-	publicKeys []PublicKey
-	services   []docdid.Service
+	publicKeys              []PublicKey
+	services                []docdid.Service
+	recoveryKeyID           string
+	nextRecoveryRevealValue []byte
+	nextUpdateRevealValue   []byte
 }
 
 // CreateDIDOption is a create DID option
@@ -247,3 +809,205 @@ func WithService(service *docdid.Service) CreateDIDOption {
 		opts.services = append(opts.services, *service)
 	}
 }
+
+// WithRecoveryKeyID set the ID of the client signer key to use as recovery key,
+// instead of the public key flagged as Recovery
+func WithRecoveryKeyID(signerKeyID string) CreateDIDOption {
+	return func(opts *CreateDIDOpts) {
+		opts.recoveryKeyID = signerKeyID
+	}
+}
+
+// WithInitialRecoveryRevealValue set the reveal value for the first recovery instead of generating one
+func WithInitialRecoveryRevealValue(revealValue []byte) CreateDIDOption {
+	return func(opts *CreateDIDOpts) {
+		opts.nextRecoveryRevealValue = revealValue
+	}
+}
+
+// WithInitialUpdateRevealValue set the reveal value for the first update instead of generating one
+func WithInitialUpdateRevealValue(revealValue []byte) CreateDIDOption {
+	return func(opts *CreateDIDOpts) {
+		opts.nextUpdateRevealValue = revealValue
+	}
+}
+
+// UpdateDIDOpts update did opts
+type UpdateDIDOpts struct {
+	addPublicKeys         []PublicKey
+	removePublicKeys      []string
+	addServices           []docdid.Service
+	removeServices        []string
+	signingKey            crypto.PrivateKey
+	signingKeyID          string
+	signerKeyID           string
+	updateRevealValue     []byte
+	nextUpdateRevealValue []byte
+}
+
+// UpdateDIDOption is an update DID option
+type UpdateDIDOption func(opts *UpdateDIDOpts)
+
+// WithAddPublicKey add DID public key
+func WithAddPublicKey(publicKey *PublicKey) UpdateDIDOption {
+	return func(opts *UpdateDIDOpts) {
+		opts.addPublicKeys = append(opts.addPublicKeys, *publicKey)
+	}
+}
+
+// WithRemovePublicKey remove DID public key
+func WithRemovePublicKey(publicKeyID string) UpdateDIDOption {
+	return func(opts *UpdateDIDOpts) {
+		opts.removePublicKeys = append(opts.removePublicKeys, publicKeyID)
+	}
+}
+
+// WithAddService add service
+func WithAddService(service *docdid.Service) UpdateDIDOption {
+	return func(opts *UpdateDIDOpts) {
+		opts.addServices = append(opts.addServices, *service)
+	}
+}
+
+// WithRemoveService remove service
+func WithRemoveService(serviceID string) UpdateDIDOption {
+	return func(opts *UpdateDIDOpts) {
+		opts.removeServices = append(opts.removeServices, serviceID)
+	}
+}
+
+// WithUpdateSigningKey set the private key used to sign the update operation and the ID of its public key
+// in the did document
+func WithUpdateSigningKey(keyID string, privateKey crypto.PrivateKey) UpdateDIDOption {
+	return func(opts *UpdateDIDOpts) {
+		opts.signingKeyID = keyID
+		opts.signingKey = privateKey
+	}
+}
+
+// WithUpdateSigningKeyID set the ID of the client signer key used to sign the update operation
+// and the ID of its public key in the did document
+func WithUpdateSigningKeyID(keyID, signerKeyID string) UpdateDIDOption {
+	return func(opts *UpdateDIDOpts) {
+		opts.signingKeyID = keyID
+		opts.signerKeyID = signerKeyID
+	}
+}
+
+// WithUpdateRevealValue set the reveal value committed to by the previous operation (required)
+func WithUpdateRevealValue(revealValue []byte) UpdateDIDOption {
+	return func(opts *UpdateDIDOpts) {
+		opts.updateRevealValue = revealValue
+	}
+}
+
+// WithNextUpdateRevealValue set the reveal value for the next update instead of generating one
+func WithNextUpdateRevealValue(revealValue []byte) UpdateDIDOption {
+	return func(opts *UpdateDIDOpts) {
+		opts.nextUpdateRevealValue = revealValue
+	}
+}
+
+// RecoverDIDOpts recover did opts
+type RecoverDIDOpts struct {
+	publicKeys              []PublicKey
+	services                []docdid.Service
+	signingKey              crypto.PrivateKey
+	signerKeyID             string
+	newRecoveryKeyID        string
+	recoveryRevealValue     []byte
+	nextRecoveryRevealValue []byte
+	nextUpdateRevealValue   []byte
+}
+
+// RecoverDIDOption is a recover DID option
+type RecoverDIDOption func(opts *RecoverDIDOpts)
+
+// WithRecoverPublicKey add DID public key to the recovered document.
+// The new recovery key is the public key flagged as Recovery.
+func WithRecoverPublicKey(publicKey *PublicKey) RecoverDIDOption {
+	return func(opts *RecoverDIDOpts) {
+		opts.publicKeys = append(opts.publicKeys, *publicKey)
+	}
+}
+
+// WithRecoverService add service to the recovered document
+func WithRecoverService(service *docdid.Service) RecoverDIDOption {
+	return func(opts *RecoverDIDOpts) {
+		opts.services = append(opts.services, *service)
+	}
+}
+
+// WithRecoverySigningKey set the current recovery private key used to sign the recover operation
+func WithRecoverySigningKey(privateKey crypto.PrivateKey) RecoverDIDOption {
+	return func(opts *RecoverDIDOpts) {
+		opts.signingKey = privateKey
+	}
+}
+
+// WithRecoverySigningKeyID set the ID of the client signer key holding the current recovery private key
+func WithRecoverySigningKeyID(signerKeyID string) RecoverDIDOption {
+	return func(opts *RecoverDIDOpts) {
+		opts.signerKeyID = signerKeyID
+	}
+}
+
+// WithNewRecoveryKeyID set the ID of the client signer key to use as the new recovery key,
+// instead of the public key flagged as Recovery
+func WithNewRecoveryKeyID(signerKeyID string) RecoverDIDOption {
+	return func(opts *RecoverDIDOpts) {
+		opts.newRecoveryKeyID = signerKeyID
+	}
+}
+
+// WithRecoveryRevealValue set the recovery reveal value committed to by the previous operation (required)
+func WithRecoveryRevealValue(revealValue []byte) RecoverDIDOption {
+	return func(opts *RecoverDIDOpts) {
+		opts.recoveryRevealValue = revealValue
+	}
+}
+
+// WithNextRecoveryRevealValue set the reveal value for the next recovery instead of generating one
+func WithNextRecoveryRevealValue(revealValue []byte) RecoverDIDOption {
+	return func(opts *RecoverDIDOpts) {
+		opts.nextRecoveryRevealValue = revealValue
+	}
+}
+
+// WithRecoverNextUpdateRevealValue set the reveal value for the next update after recovery instead of generating one
+func WithRecoverNextUpdateRevealValue(revealValue []byte) RecoverDIDOption {
+	return func(opts *RecoverDIDOpts) {
+		opts.nextUpdateRevealValue = revealValue
+	}
+}
+
+// DeactivateDIDOpts deactivate did opts
+type DeactivateDIDOpts struct {
+	signingKey          crypto.PrivateKey
+	signerKeyID         string
+	recoveryRevealValue []byte
+}
+
+// DeactivateDIDOption is a deactivate DID option
+type DeactivateDIDOption func(opts *DeactivateDIDOpts)
+
+// WithDeactivateSigningKey set the current recovery private key used to sign the deactivate operation
+func WithDeactivateSigningKey(privateKey crypto.PrivateKey) DeactivateDIDOption {
+	return func(opts *DeactivateDIDOpts) {
+		opts.signingKey = privateKey
+	}
+}
+
+// WithDeactivateSigningKeyID set the ID of the client signer key holding the current recovery private key
+func WithDeactivateSigningKeyID(signerKeyID string) DeactivateDIDOption {
+	return func(opts *DeactivateDIDOpts) {
+		opts.signerKeyID = signerKeyID
+	}
+}
+
+// WithDeactivateRecoveryRevealValue set the recovery reveal value committed to by the previous operation (required)
+func WithDeactivateRecoveryRevealValue(revealValue []byte) DeactivateDIDOption {
+	return func(opts *DeactivateDIDOpts) {
+		opts.recoveryRevealValue = revealValue
+	}
+}
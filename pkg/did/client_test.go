@@ -6,6 +6,7 @@ SPDX-License-Identifier: Apache-2.0
 package did
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"crypto/ed25519"
 	"crypto/elliptic"
@@ -13,12 +14,23 @@ import (
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/btcsuite/btcd/btcec"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
 	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/protocol"
+	"github.com/trustbloc/sidetree-core-go/pkg/composer"
+	"github.com/trustbloc/sidetree-core-go/pkg/dochandler/didvalidator"
+	"github.com/trustbloc/sidetree-core-go/pkg/document"
+	"github.com/trustbloc/sidetree-core-go/pkg/docutil"
+	"github.com/trustbloc/sidetree-core-go/pkg/operation"
+	"github.com/trustbloc/sidetree-core-go/pkg/restapi/model"
 
 	mockdiscovery "github.com/trustbloc/trustbloc-did-method/pkg/internal/mock/discovery"
 	mockendpoint "github.com/trustbloc/trustbloc-did-method/pkg/internal/mock/endpoint"
@@ -136,6 +148,138 @@ func TestClient_CreateDID(t *testing.T) {
 		require.Nil(t, doc)
 	})
 
+	t.Run("test failover to next endpoint", func(t *testing.T) {
+		ed25519PubKey, _, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		var requested []string
+
+		newServer := func(name string, status int) *httptest.Server {
+			return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requested = append(requested, name)
+
+				w.WriteHeader(status)
+
+				if status == http.StatusOK {
+					bytes, err := (&did.Doc{ID: "did1", Context: []string{did.Context}}).JSONBytes()
+					require.NoError(t, err)
+					_, err = w.Write(bytes)
+					require.NoError(t, err)
+				}
+			}))
+		}
+
+		failing1 := newServer("s1-a", http.StatusInternalServerError)
+		defer failing1.Close()
+
+		failing2 := newServer("s1-b", http.StatusServiceUnavailable)
+		defer failing2.Close()
+
+		badRequest := newServer("s2-a", http.StatusBadRequest)
+		defer badRequest.Close()
+
+		working := newServer("s2-b", http.StatusOK)
+		defer working.Close()
+
+		recoveryKey := WithPublicKey(&PublicKey{ID: "key1", Encoding: PublicKeyEncodingJwk,
+			Recovery: true, Value: ed25519PubKey, KeyType: Ed25519KeyType})
+
+		// endpoints of the second stakeholder are interleaved with the endpoints of the first one
+		v := New()
+		v.endpointService = &mockendpoint.MockEndpointService{
+			GetEndpointsFunc: func(domain string) (endpoints []*models.Endpoint, err error) {
+				return []*models.Endpoint{{URL: failing1.URL, Domain: "s1"}, {URL: failing2.URL, Domain: "s1"},
+					{URL: "url", Domain: "s2"}, {URL: working.URL, Domain: "s2"}}, nil
+			}}
+
+		result, err := v.CreateDID("testnet", recoveryKey)
+		require.NoError(t, err)
+		require.Equal(t, "did1", result.DIDDoc.ID)
+		require.Equal(t, []string{"s1-a", "s1-b", "s2-b"}, requested)
+
+		// attempt budget
+		requested = nil
+		v = New(WithCreateAttempts(2))
+		v.endpointService = &mockendpoint.MockEndpointService{
+			GetEndpointsFunc: func(domain string) (endpoints []*models.Endpoint, err error) {
+				return []*models.Endpoint{{URL: failing1.URL, Domain: "s1"}, {URL: failing2.URL, Domain: "s1"},
+					{URL: working.URL, Domain: "s1"}}, nil
+			}}
+
+		result, err = v.CreateDID("testnet", recoveryKey)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "endpoint "+failing1.URL+": got unexpected response")
+		require.Contains(t, err.Error(), "endpoint "+failing2.URL+": got unexpected response")
+		require.Nil(t, result)
+		require.Equal(t, []string{"s1-a", "s1-b"}, requested)
+
+		// no failover on client errors
+		requested = nil
+		v = New()
+		v.endpointService = &mockendpoint.MockEndpointService{
+			GetEndpointsFunc: func(domain string) (endpoints []*models.Endpoint, err error) {
+				return []*models.Endpoint{{URL: badRequest.URL, Domain: "s2"}, {URL: working.URL, Domain: "s2"}}, nil
+			}}
+
+		result, err = v.CreateDID("testnet", recoveryKey)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "status '400'")
+		require.Nil(t, result)
+		require.Equal(t, []string{"s2-a"}, requested)
+
+		// endpoints with an open circuit are skipped
+		requested = nil
+		v = New(WithCircuitBreaker(1, time.Hour))
+		v.endpointService = &mockendpoint.MockEndpointService{
+			GetEndpointsFunc: func(domain string) (endpoints []*models.Endpoint, err error) {
+				return []*models.Endpoint{{URL: failing1.URL, Domain: "s1"}, {URL: working.URL, Domain: "s2"}}, nil
+			}}
+
+		for i := 0; i < 2; i++ {
+			result, err = v.CreateDID("testnet", recoveryKey)
+			require.NoError(t, err)
+			require.Equal(t, "did1", result.DIDDoc.ID)
+		}
+
+		require.Equal(t, []string{"s1-a", "s2-b", "s2-b"}, requested)
+	})
+
+	t.Run("test cancelled context", func(t *testing.T) {
+		ed25519PubKey, _, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		var requests int32
+
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests, 1)
+
+			// the request context is only cancelled once the body is read
+			_, err := ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+
+			<-r.Context().Done()
+		}))
+		defer serv.Close()
+
+		v := New()
+		v.endpointService = &mockendpoint.MockEndpointService{
+			GetEndpointsFunc: func(domain string) (endpoints []*models.Endpoint, err error) {
+				return []*models.Endpoint{{URL: serv.URL, Domain: "s1"}, {URL: serv.URL, Domain: "s2"}}, nil
+			}}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		result, err := v.CreateDIDWithContext(ctx, "testnet", WithPublicKey(&PublicKey{ID: "key1",
+			Encoding: PublicKeyEncodingJwk, Recovery: true, Value: ed25519PubKey, KeyType: Ed25519KeyType}))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "context deadline exceeded")
+		require.Nil(t, result)
+
+		// no failover once the context is done
+		require.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	})
+
 	t.Run("test success", func(t *testing.T) {
 		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			bytes, err := (&did.Doc{ID: "did1", Context: []string{did.Context}}).JSONBytes()
@@ -163,7 +307,7 @@ func TestClient_CreateDID(t *testing.T) {
 				return []*models.Endpoint{{URL: serv.URL}}, nil
 			}}
 
-		doc, err := v.CreateDID("testnet", WithPublicKey(&PublicKey{
+		result, err := v.CreateDID("testnet", WithPublicKey(&PublicKey{
 			Type: Ed25519VerificationKey2018, Encoding: PublicKeyEncodingJwk, Value: ed25519PubKey, Recovery: true}),
 			WithPublicKey(&PublicKey{ID: "key2",
 				Type: JWSVerificationKey2020, Encoding: PublicKeyEncodingJwk, KeyType: Ed25519KeyType,
@@ -179,7 +323,17 @@ func TestClient_CreateDID(t *testing.T) {
 			WithService(&did.Service{ID: "srv1", Type: "type", ServiceEndpoint: "http://example.com",
 				Properties: map[string]interface{}{"k1": "v1"}}))
 		require.NoError(t, err)
-		require.Equal(t, "did1", doc.ID)
+		require.Equal(t, "did1", result.DIDDoc.ID)
+		require.Len(t, result.UpdateRevealValue, revealValueLength)
+		require.Len(t, result.RecoveryRevealValue, revealValueLength)
+		require.NotEqual(t, result.UpdateRevealValue, result.RecoveryRevealValue)
+
+		result, err = v.CreateDID("testnet", WithPublicKey(&PublicKey{
+			Type: Ed25519VerificationKey2018, Encoding: PublicKeyEncodingJwk, Value: ed25519PubKey, Recovery: true}),
+			WithInitialUpdateRevealValue([]byte("update")), WithInitialRecoveryRevealValue([]byte("recovery")))
+		require.NoError(t, err)
+		require.Equal(t, []byte("update"), result.UpdateRevealValue)
+		require.Equal(t, []byte("recovery"), result.RecoveryRevealValue)
 	})
 
 	t.Run("test create DID - invalid key type", func(t *testing.T) {
@@ -238,7 +392,7 @@ func TestClient_CreateDID(t *testing.T) {
 			}),
 		)
 		require.Error(t, err)
-		require.Contains(t, err.Error(), "invalid EC key")
+		require.Contains(t, err.Error(), "invalid P256 public key")
 		require.Nil(t, doc)
 	})
 
@@ -265,12 +419,202 @@ func TestClient_CreateDID(t *testing.T) {
 			Type: JWSVerificationKey2020, Encoding: "wrong", Value: pubKey, Recovery: true}),
 			WithPublicKey(&PublicKey{ID: "#key2",
 				Type: JWSVerificationKey2020, Encoding: PublicKeyEncodingJwk, KeyType: Ed25519KeyType,
-				Value: []byte("value")}))
+				Value: pubKey}))
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "failed to get recovery key")
 		require.Nil(t, doc)
 	})
 
+	t.Run("test recovery key types", func(t *testing.T) {
+		var createRequest map[string]interface{}
+
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&createRequest))
+
+			bytes, err := (&did.Doc{ID: "did1", Context: []string{did.Context}}).JSONBytes()
+			require.NoError(t, err)
+			_, err = fmt.Fprint(w, string(bytes))
+			require.NoError(t, err)
+		}))
+		defer serv.Close()
+
+		ed25519PubKey, _, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		p256PrivKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		secp256k1PrivKey, err := btcec.NewPrivateKey(btcec.S256())
+		require.NoError(t, err)
+
+		v := New()
+
+		v.endpointService = &mockendpoint.MockEndpointService{
+			GetEndpointsFunc: func(domain string) (endpoints []*models.Endpoint, err error) {
+				return []*models.Endpoint{{URL: serv.URL}}, nil
+			}}
+
+		docKey := WithPublicKey(&PublicKey{ID: "key1", Type: JWSVerificationKey2020, Encoding: PublicKeyEncodingJwk,
+			KeyType: Ed25519KeyType, Value: ed25519PubKey, Usage: []string{KeyUsageGeneral}})
+
+		for keyType, expected := range map[string]struct {
+			value []byte
+			crv   string
+		}{
+			Ed25519KeyType:   {value: ed25519PubKey, crv: "Ed25519"},
+			P256KeyType:      {value: elliptic.Marshal(elliptic.P256(), p256PrivKey.X, p256PrivKey.Y), crv: "P-256"},
+			Secp256k1KeyType: {value: secp256k1PrivKey.PubKey().SerializeCompressed(), crv: "secp256k1"},
+		} {
+			result, err := v.CreateDID("testnet", docKey, WithPublicKey(&PublicKey{Encoding: PublicKeyEncodingJwk,
+				KeyType: keyType, Value: expected.value, Recovery: true}))
+			require.NoError(t, err, keyType)
+			require.Equal(t, "did1", result.DIDDoc.ID)
+
+			suffixData, err := docutil.DecodeString(createRequest["suffix_data"].(string))
+			require.NoError(t, err)
+
+			var suffixDataModel model.SuffixDataModel
+			require.NoError(t, json.Unmarshal(suffixData, &suffixDataModel))
+			require.Equal(t, expected.crv, suffixDataModel.RecoveryKey.Crv, keyType)
+		}
+
+		result, err := v.CreateDID("testnet", docKey, WithPublicKey(&PublicKey{Type: Ed25519VerificationKey2018,
+			Encoding: PublicKeyEncodingBase58, KeyType: Ed25519KeyType, Value: ed25519PubKey, Recovery: true}))
+		require.NoError(t, err)
+		require.Equal(t, "did1", result.DIDDoc.ID)
+
+		result, err = v.CreateDID("testnet", docKey, WithPublicKey(&PublicKey{Encoding: PublicKeyEncodingBase58,
+			KeyType: P256KeyType, Value: elliptic.Marshal(elliptic.P256(), p256PrivKey.X, p256PrivKey.Y),
+			Recovery: true}))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Base58 encoding is only supported for Ed25519VerificationKey2018")
+		require.Nil(t, result)
+
+		result, err = v.CreateDID("testnet", docKey, WithPublicKey(&PublicKey{Encoding: PublicKeyEncodingJwk,
+			KeyType: "RSA", Value: ed25519PubKey, Recovery: true}))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid key type: RSA")
+		require.Nil(t, result)
+
+		result, err = v.CreateDID("testnet", docKey, WithPublicKey(&PublicKey{Encoding: PublicKeyEncodingJwk,
+			KeyType: Secp256k1KeyType, Value: ed25519PubKey, Recovery: true}))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid secp256k1 public key")
+		require.Nil(t, result)
+	})
+
+	t.Run("test document key types", func(t *testing.T) {
+		var createRequest model.CreateRequest
+
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&createRequest))
+
+			bytes, err := (&did.Doc{ID: "did1", Context: []string{did.Context}}).JSONBytes()
+			require.NoError(t, err)
+			_, err = fmt.Fprint(w, string(bytes))
+			require.NoError(t, err)
+		}))
+		defer serv.Close()
+
+		ed25519PubKey, _, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		secp256k1PrivKey, err := btcec.NewPrivateKey(btcec.S256())
+		require.NoError(t, err)
+
+		x25519PubKey := make([]byte, 32)
+		_, err = rand.Read(x25519PubKey)
+		require.NoError(t, err)
+
+		v := New()
+
+		v.endpointService = &mockendpoint.MockEndpointService{
+			GetEndpointsFunc: func(domain string) (endpoints []*models.Endpoint, err error) {
+				return []*models.Endpoint{{URL: serv.URL}}, nil
+			}}
+
+		recoveryKey := WithPublicKey(&PublicKey{Encoding: PublicKeyEncodingJwk, KeyType: Ed25519KeyType,
+			Value: ed25519PubKey, Recovery: true})
+
+		result, err := v.CreateDID("testnet", recoveryKey,
+			WithPublicKey(&PublicKey{ID: "key1", Type: EcdsaSecp256k1VerificationKey2019,
+				Encoding: PublicKeyEncodingJwk, KeyType: Secp256k1KeyType,
+				Value: secp256k1PrivKey.PubKey().SerializeUncompressed(), Usage: []string{KeyUsageGeneral}}),
+			WithPublicKey(&PublicKey{ID: "key2", Type: X25519KeyAgreementKey2019, Encoding: PublicKeyEncodingJwk,
+				KeyType: X25519KeyType, Value: x25519PubKey, Usage: []string{KeyUsageAgreement}}))
+		require.NoError(t, err)
+		require.Equal(t, "did1", result.DIDDoc.ID)
+
+		delta, err := docutil.DecodeString(createRequest.Delta)
+		require.NoError(t, err)
+		require.Contains(t, string(delta), EcdsaSecp256k1VerificationKey2019)
+		require.Contains(t, string(delta), `"crv":"secp256k1"`)
+		require.Contains(t, string(delta), X25519KeyAgreementKey2019)
+		require.Contains(t, string(delta), `"crv":"X25519"`)
+
+		result, err = v.CreateDID("testnet", recoveryKey, WithPublicKey(&PublicKey{ID: "key2",
+			Type: X25519KeyAgreementKey2019, Encoding: PublicKeyEncodingJwk, KeyType: X25519KeyType,
+			Value: x25519PubKey[:16], Usage: []string{KeyUsageAgreement}}))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid X25519 public key size: 16")
+		require.Nil(t, result)
+
+		result, err = v.CreateDID("testnet", WithPublicKey(&PublicKey{Encoding: PublicKeyEncodingJwk,
+			KeyType: X25519KeyType, Value: x25519PubKey, Recovery: true}))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "X25519 key can't be used as recovery key")
+		require.Nil(t, result)
+	})
+
+	t.Run("test X25519 key agreement key round trip", func(t *testing.T) {
+		// the create request is applied and the document built the same way a sidetree node does
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			request, err := ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+
+			op, err := operation.ParseCreateOperation(request, protocol.Protocol{HashAlgorithmInMultiHashCode: sha2_256})
+			require.NoError(t, err)
+
+			internal, err := composer.ApplyPatches(make(document.Document), op.Delta.Patches)
+			require.NoError(t, err)
+
+			internal[document.IDProperty] = "did:trustbloc:testnet:" + op.UniqueSuffix
+
+			result, err := didvalidator.New(nil).TransformDocument(internal)
+			require.NoError(t, err)
+
+			bytes, err := result.Document.Bytes()
+			require.NoError(t, err)
+			_, err = w.Write(bytes)
+			require.NoError(t, err)
+		}))
+		defer serv.Close()
+
+		ed25519PubKey, _, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		x25519PubKey := make([]byte, 32)
+		_, err = rand.Read(x25519PubKey)
+		require.NoError(t, err)
+
+		v := New()
+
+		v.endpointService = &mockendpoint.MockEndpointService{
+			GetEndpointsFunc: func(domain string) (endpoints []*models.Endpoint, err error) {
+				return []*models.Endpoint{{URL: serv.URL}}, nil
+			}}
+
+		result, err := v.CreateDID("testnet", WithPublicKey(&PublicKey{Encoding: PublicKeyEncodingJwk,
+			KeyType: Ed25519KeyType, Value: ed25519PubKey, Recovery: true}),
+			WithPublicKey(&PublicKey{ID: "key2", Type: X25519KeyAgreementKey2019, Encoding: PublicKeyEncodingJwk,
+				KeyType: X25519KeyType, Value: x25519PubKey, Usage: []string{KeyUsageGeneral, KeyUsageAgreement}}))
+		require.NoError(t, err)
+		require.Len(t, result.DIDDoc.PublicKey, 1)
+		require.Equal(t, result.DIDDoc.ID+"#key2", result.DIDDoc.PublicKey[0].ID)
+		require.Equal(t, X25519KeyAgreementKey2019, result.DIDDoc.PublicKey[0].Type)
+		require.Equal(t, x25519PubKey, result.DIDDoc.PublicKey[0].Value)
+	})
+
 	t.Run("test recovery public key empty", func(t *testing.T) {
 		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			bytes, err := (&did.Doc{ID: "did1", Context: []string{did.Context}}).JSONBytes()
@@ -299,6 +643,42 @@ func TestClient_CreateDID(t *testing.T) {
 		require.Nil(t, doc)
 	})
 
+	t.Run("test recovery key from signer", func(t *testing.T) {
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			bytes, err := (&did.Doc{ID: "did1", Context: []string{did.Context}}).JSONBytes()
+			require.NoError(t, err)
+			_, err = fmt.Fprint(w, string(bytes))
+			require.NoError(t, err)
+		}))
+		defer serv.Close()
+
+		pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		v := New()
+
+		v.endpointService = &mockendpoint.MockEndpointService{
+			GetEndpointsFunc: func(domain string) (endpoints []*models.Endpoint, err error) {
+				return []*models.Endpoint{{URL: serv.URL}}, nil
+			}}
+
+		publicKey := WithPublicKey(&PublicKey{ID: "key1", Type: JWSVerificationKey2020, Encoding: PublicKeyEncodingJwk,
+			Value: pubKey, KeyType: Ed25519KeyType, Usage: []string{KeyUsageGeneral}})
+
+		result, err := v.CreateDID("testnet", publicKey, WithRecoveryKeyID("recovery"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "signer is not configured")
+		require.Nil(t, result)
+
+		signer := NewInMemorySigner()
+		require.NoError(t, signer.AddKey("recovery", privKey))
+		v.signer = signer
+
+		result, err = v.CreateDID("testnet", publicKey, WithRecoveryKeyID("recovery"))
+		require.NoError(t, err)
+		require.Equal(t, "did1", result.DIDDoc.ID)
+	})
+
 	t.Run("test unsupported public key encoding", func(t *testing.T) {
 		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			bytes, err := (&did.Doc{ID: "did1", Context: []string{did.Context}}).JSONBytes()
@@ -325,12 +705,38 @@ func TestClient_CreateDID(t *testing.T) {
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "public key encoding not supported")
 		require.Nil(t, doc)
+
+		for _, encoding := range []string{"Multibase", "Pem"} {
+			doc, err = v.CreateDID("testnet", WithPublicKey(&PublicKey{ID: "#key1",
+				Type: JWSVerificationKey2020, Encoding: PublicKeyEncodingJwk, Value: pubKey, Recovery: true}),
+				WithPublicKey(&PublicKey{ID: "#key2", Type: Ed25519VerificationKey2018, Encoding: encoding,
+					Value: pubKey, Usage: []string{KeyUsageGeneral}}))
+			require.Error(t, err, encoding)
+			require.Contains(t, err.Error(), "public key encoding not supported: "+encoding)
+			require.Nil(t, doc)
+		}
+
+		doc, err = v.CreateDID("testnet", WithPublicKey(&PublicKey{ID: "#key1",
+			Type: JWSVerificationKey2020, Encoding: PublicKeyEncodingJwk, Value: pubKey, Recovery: true}),
+			WithPublicKey(&PublicKey{ID: "#key2", Type: JWSVerificationKey2020, Encoding: PublicKeyEncodingBase58,
+				Value: pubKey, Usage: []string{KeyUsageGeneral}}))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Base58 encoding is only supported for Ed25519VerificationKey2018")
+		require.Nil(t, doc)
+
+		doc, err = v.CreateDID("testnet", WithPublicKey(&PublicKey{ID: "#key1",
+			Type: JWSVerificationKey2020, Encoding: PublicKeyEncodingJwk, Value: pubKey, Recovery: true}),
+			WithPublicKey(&PublicKey{ID: "key2", Type: Ed25519VerificationKey2018, Encoding: PublicKeyEncodingBase58,
+				KeyType: Ed25519KeyType, Value: pubKey, Usage: []string{KeyUsageGeneral}}))
+		require.NoError(t, err)
+		require.Equal(t, "did1", doc.DIDDoc.ID)
 	})
 
 	t.Run("test opts", func(t *testing.T) {
 		// test WithTLSConfig
 		var opts []Option
-		opts = append(opts, WithTLSConfig(&tls.Config{ServerName: "test"}), WithAuthToken("tk1"))
+		opts = append(opts, WithTLSConfig(&tls.Config{ServerName: "test"}), WithAuthToken("tk1"),
+			WithTimeout(time.Second), WithRetry(2, time.Millisecond, time.Second), WithCircuitBreaker(5, time.Minute))
 
 		c := &Client{}
 
@@ -341,6 +747,9 @@ func TestClient_CreateDID(t *testing.T) {
 
 		require.Equal(t, "test", c.tlsConfig.ServerName)
 		require.Equal(t, "Bearer tk1", c.authToken)
+		require.Equal(t, time.Second, c.timeout)
+		require.Len(t, c.transportOpts, 2)
+		require.Len(t, c.configOpts, 2)
 
 		// test WithPublicKey
 		var createOpts []CreateDIDOption
@@ -370,6 +779,402 @@ func TestClient_CreateDID(t *testing.T) {
 	})
 }
 
+func TestClient_UpdateDID(t *testing.T) {
+	ed25519PubKey, ed25519PrivKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	revealValue := WithUpdateRevealValue([]byte("reveal"))
+
+	t.Run("test update reveal value missing", func(t *testing.T) {
+		v := New()
+
+		result, err := v.UpdateDID("did:trustbloc:testnet:123")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "update reveal value is required")
+		require.Nil(t, result)
+	})
+
+	t.Run("test wrong did", func(t *testing.T) {
+		v := New()
+
+		result, err := v.UpdateDID("did:1223", revealValue)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid did did:1223")
+		require.Nil(t, result)
+	})
+
+	t.Run("test error from get endpoints", func(t *testing.T) {
+		v := New()
+
+		v.endpointService = endpoint.NewService(
+			discoveryMock(nil, fmt.Errorf("discover error")),
+			selectionMock(nil, nil))
+
+		result, err := v.UpdateDID("did:trustbloc:testnet:123", revealValue)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "discover error")
+		require.Nil(t, result)
+
+		v.endpointService = endpoint.NewService(
+			discoveryMock(nil, nil),
+			selectionMock(nil, nil))
+
+		result, err = v.UpdateDID("did:trustbloc:testnet:123", revealValue)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "list of endpoints is empty")
+		require.Nil(t, result)
+	})
+
+	t.Run("test error from build update request", func(t *testing.T) {
+		v := New()
+
+		v.endpointService = &mockendpoint.MockEndpointService{
+			GetEndpointsFunc: func(domain string) (endpoints []*models.Endpoint, err error) {
+				return []*models.Endpoint{{URL: "url"}}, nil
+			}}
+
+		result, err := v.UpdateDID("did:trustbloc:testnet:123", revealValue, WithUpdateSigningKey("key1", ed25519PrivKey))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "no update patch provided")
+		require.Nil(t, result)
+
+		result, err = v.UpdateDID("did:trustbloc:testnet:123", revealValue, WithUpdateSigningKey("key1", ed25519PrivKey),
+			WithRemovePublicKey("key2"), WithRemoveService("svc1"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "only one patch action is supported per update operation")
+		require.Nil(t, result)
+
+		result, err = v.UpdateDID("did:trustbloc:testnet:123", revealValue, WithRemovePublicKey("key2"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "signing key is required")
+		require.Nil(t, result)
+
+		result, err = v.UpdateDID("did:trustbloc:testnet:123", revealValue, WithRemovePublicKey("key2"),
+			WithUpdateSigningKey("key1", "wrong"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "private key type not supported")
+		require.Nil(t, result)
+
+		result, err = v.UpdateDID("did:trustbloc:testnet:123", revealValue, WithUpdateSigningKey("key1", ed25519PrivKey),
+			WithAddPublicKey(&PublicKey{ID: "key2", Encoding: "wrong"}))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "public key encoding not supported")
+		require.Nil(t, result)
+
+		result, err = v.UpdateDID("did:trustbloc:testnet:123", revealValue, WithUpdateSigningKey("", ed25519PrivKey),
+			WithRemovePublicKey("key2"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "kid has to be provided for update signer")
+		require.Nil(t, result)
+	})
+
+	t.Run("test error from send update sidetree request", func(t *testing.T) {
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer serv.Close()
+
+		v := New()
+
+		v.endpointService = &mockendpoint.MockEndpointService{
+			GetEndpointsFunc: func(domain string) (endpoints []*models.Endpoint, err error) {
+				return []*models.Endpoint{{URL: serv.URL}}, nil
+			}}
+
+		result, err := v.UpdateDID("did:trustbloc:testnet:123", revealValue, WithUpdateSigningKey("key1", ed25519PrivKey),
+			WithRemovePublicKey("key2"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to send update sidetree request")
+		require.Nil(t, result)
+	})
+
+	t.Run("test success", func(t *testing.T) {
+		ecPrivKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		var updateRequest map[string]interface{}
+
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&updateRequest))
+			_, err := fmt.Fprint(w, "null")
+			require.NoError(t, err)
+		}))
+		defer serv.Close()
+
+		v := New()
+
+		v.endpointService = &mockendpoint.MockEndpointService{
+			GetEndpointsFunc: func(domain string) (endpoints []*models.Endpoint, err error) {
+				return []*models.Endpoint{{URL: serv.URL}}, nil
+			}}
+
+		result, err := v.UpdateDID("did:trustbloc:testnet:123", revealValue,
+			WithUpdateSigningKey("key1", ed25519PrivKey),
+			WithAddPublicKey(&PublicKey{ID: "key2", Type: JWSVerificationKey2020, Encoding: PublicKeyEncodingJwk,
+				KeyType: Ed25519KeyType, Value: ed25519PubKey, Usage: []string{KeyUsageGeneral}}))
+		require.NoError(t, err)
+		require.Nil(t, result.DIDDoc)
+		require.Len(t, result.UpdateRevealValue, revealValueLength)
+		require.Nil(t, result.RecoveryRevealValue)
+		require.Equal(t, "update", updateRequest["type"])
+		require.Equal(t, "123", updateRequest["did_suffix"])
+
+		result, err = v.UpdateDID("did:trustbloc:testnet:123", revealValue, WithUpdateSigningKey("key1", ecPrivKey),
+			WithAddService(&did.Service{ID: "svc1", Type: "type", ServiceEndpoint: "http://example.com"}),
+			WithNextUpdateRevealValue([]byte("next")))
+		require.NoError(t, err)
+		require.Nil(t, result.DIDDoc)
+		require.Equal(t, []byte("next"), result.UpdateRevealValue)
+
+		result, err = v.UpdateDID("did:trustbloc:testnet:123", revealValue,
+			WithUpdateSigningKey("key1", ed25519PrivKey), WithRemoveService("svc1"))
+		require.NoError(t, err)
+		require.Nil(t, result.DIDDoc)
+	})
+
+	t.Run("test success with document in response", func(t *testing.T) {
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			bytes, err := (&did.Doc{ID: "did1", Context: []string{did.Context}}).JSONBytes()
+			require.NoError(t, err)
+			_, err = fmt.Fprint(w, string(bytes))
+			require.NoError(t, err)
+		}))
+		defer serv.Close()
+
+		v := New()
+
+		v.endpointService = &mockendpoint.MockEndpointService{
+			GetEndpointsFunc: func(domain string) (endpoints []*models.Endpoint, err error) {
+				return []*models.Endpoint{{URL: serv.URL}}, nil
+			}}
+
+		result, err := v.UpdateDID("did:trustbloc:testnet:123", revealValue,
+			WithUpdateSigningKey("key1", ed25519PrivKey), WithRemovePublicKey("key2"))
+		require.NoError(t, err)
+		require.Equal(t, "did1", result.DIDDoc.ID)
+	})
+}
+
+func TestClient_RecoverDID(t *testing.T) {
+	ed25519PubKey, ed25519PrivKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	recoveryRevealValue := WithRecoveryRevealValue([]byte("reveal"))
+
+	t.Run("test recovery reveal value missing", func(t *testing.T) {
+		v := New()
+
+		result, err := v.RecoverDID("did:trustbloc:testnet:123")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "recovery reveal value is required")
+		require.Nil(t, result)
+	})
+
+	t.Run("test wrong did", func(t *testing.T) {
+		v := New()
+
+		result, err := v.RecoverDID("did:1223", recoveryRevealValue)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid did did:1223")
+		require.Nil(t, result)
+	})
+
+	t.Run("test error from build recover request", func(t *testing.T) {
+		v := New()
+
+		v.endpointService = &mockendpoint.MockEndpointService{
+			GetEndpointsFunc: func(domain string) (endpoints []*models.Endpoint, err error) {
+				return []*models.Endpoint{{URL: "url"}}, nil
+			}}
+
+		result, err := v.RecoverDID("did:trustbloc:testnet:123", recoveryRevealValue, WithRecoverySigningKey(ed25519PrivKey),
+			WithRecoverPublicKey(&PublicKey{ID: "key2", Type: JWSVerificationKey2020,
+				Encoding: PublicKeyEncodingJwk, KeyType: Ed25519KeyType, Value: ed25519PubKey}))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "recovery key not found")
+		require.Nil(t, result)
+
+		result, err = v.RecoverDID("did:trustbloc:testnet:123", recoveryRevealValue,
+			WithRecoverPublicKey(&PublicKey{Encoding: PublicKeyEncodingJwk, Value: ed25519PubKey, Recovery: true}))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "signing key is required")
+		require.Nil(t, result)
+	})
+
+	t.Run("test error from send recover sidetree request", func(t *testing.T) {
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer serv.Close()
+
+		v := New()
+
+		v.endpointService = &mockendpoint.MockEndpointService{
+			GetEndpointsFunc: func(domain string) (endpoints []*models.Endpoint, err error) {
+				return []*models.Endpoint{{URL: serv.URL}}, nil
+			}}
+
+		result, err := v.RecoverDID("did:trustbloc:testnet:123", recoveryRevealValue, WithRecoverySigningKey(ed25519PrivKey),
+			WithRecoverPublicKey(&PublicKey{Encoding: PublicKeyEncodingJwk, Value: ed25519PubKey, Recovery: true}))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to send recover sidetree request")
+		require.Nil(t, result)
+	})
+
+	t.Run("test success", func(t *testing.T) {
+		var recoverRequest map[string]interface{}
+
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&recoverRequest))
+		}))
+		defer serv.Close()
+
+		newRecoveryPubKey, _, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		v := New()
+
+		v.endpointService = &mockendpoint.MockEndpointService{
+			GetEndpointsFunc: func(domain string) (endpoints []*models.Endpoint, err error) {
+				return []*models.Endpoint{{URL: serv.URL}}, nil
+			}}
+
+		recoveryKey := WithRecoverPublicKey(&PublicKey{Encoding: PublicKeyEncodingJwk, Value: newRecoveryPubKey,
+			Recovery: true})
+
+		result, err := v.RecoverDID("did:trustbloc:testnet:123", recoveryRevealValue,
+			WithRecoverySigningKey(ed25519PrivKey), recoveryKey,
+			WithRecoverPublicKey(&PublicKey{ID: "key2", Type: JWSVerificationKey2020,
+				Encoding: PublicKeyEncodingJwk, KeyType: Ed25519KeyType, Value: ed25519PubKey,
+				Usage: []string{KeyUsageOps}}),
+			WithRecoverService(&did.Service{ID: "svc1", Type: "type", ServiceEndpoint: "http://example.com"}),
+			WithNextRecoveryRevealValue([]byte("nextRecovery")),
+			WithRecoverNextUpdateRevealValue([]byte("nextUpdate")))
+		require.NoError(t, err)
+		require.Nil(t, result.DIDDoc)
+		require.Equal(t, []byte("nextRecovery"), result.RecoveryRevealValue)
+		require.Equal(t, []byte("nextUpdate"), result.UpdateRevealValue)
+		require.Equal(t, "recover", recoverRequest["type"])
+		require.Equal(t, "123", recoverRequest["did_suffix"])
+
+		result, err = v.RecoverDID("did:trustbloc:testnet:123", recoveryRevealValue,
+			WithRecoverySigningKey(ed25519PrivKey), recoveryKey)
+		require.NoError(t, err)
+		require.Len(t, result.RecoveryRevealValue, revealValueLength)
+		require.Len(t, result.UpdateRevealValue, revealValueLength)
+	})
+}
+
+func TestClient_DeactivateDID(t *testing.T) {
+	_, ed25519PrivKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	deactivateRevealValue := WithDeactivateRecoveryRevealValue([]byte("reveal"))
+
+	t.Run("test recovery reveal value missing", func(t *testing.T) {
+		v := New()
+
+		err := v.DeactivateDID("did:trustbloc:testnet:123")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "recovery reveal value is required")
+	})
+
+	t.Run("test wrong did", func(t *testing.T) {
+		v := New()
+
+		err := v.DeactivateDID("did:1223", deactivateRevealValue)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid did did:1223")
+	})
+
+	t.Run("test error from build deactivate request", func(t *testing.T) {
+		v := New()
+
+		v.endpointService = &mockendpoint.MockEndpointService{
+			GetEndpointsFunc: func(domain string) (endpoints []*models.Endpoint, err error) {
+				return []*models.Endpoint{{URL: "url"}}, nil
+			}}
+
+		err := v.DeactivateDID("did:trustbloc:testnet:123", deactivateRevealValue)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "signing key is required")
+
+		err = v.DeactivateDID("did:trustbloc:testnet:123", deactivateRevealValue, WithDeactivateSigningKeyID("k1"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "signer is not configured")
+
+		v.signer = NewInMemorySigner()
+
+		err = v.DeactivateDID("did:trustbloc:testnet:123", deactivateRevealValue, WithDeactivateSigningKeyID("k1"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "key not found: k1")
+	})
+
+	t.Run("test error from send deactivate sidetree request", func(t *testing.T) {
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer serv.Close()
+
+		v := New()
+
+		v.endpointService = &mockendpoint.MockEndpointService{
+			GetEndpointsFunc: func(domain string) (endpoints []*models.Endpoint, err error) {
+				return []*models.Endpoint{{URL: serv.URL}}, nil
+			}}
+
+		err := v.DeactivateDID("did:trustbloc:testnet:123", deactivateRevealValue, WithDeactivateSigningKey(ed25519PrivKey))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to send deactivate sidetree request")
+	})
+
+	t.Run("test success", func(t *testing.T) {
+		var deactivateRequest map[string]interface{}
+
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&deactivateRequest))
+		}))
+		defer serv.Close()
+
+		v := New()
+
+		v.endpointService = &mockendpoint.MockEndpointService{
+			GetEndpointsFunc: func(domain string) (endpoints []*models.Endpoint, err error) {
+				return []*models.Endpoint{{URL: serv.URL}}, nil
+			}}
+
+		err := v.DeactivateDID("did:trustbloc:testnet:123", deactivateRevealValue, WithDeactivateSigningKey(ed25519PrivKey),
+			WithDeactivateRecoveryRevealValue([]byte("reveal")))
+		require.NoError(t, err)
+		require.Equal(t, "deactivate", deactivateRequest["type"])
+		require.Equal(t, "123", deactivateRequest["did_suffix"])
+	})
+
+	t.Run("test success with signer", func(t *testing.T) {
+		var deactivateRequest map[string]interface{}
+
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&deactivateRequest))
+		}))
+		defer serv.Close()
+
+		signer := NewInMemorySigner()
+		require.NoError(t, signer.AddKey("recovery", ed25519PrivKey))
+
+		v := New(WithSigner(signer))
+
+		v.endpointService = &mockendpoint.MockEndpointService{
+			GetEndpointsFunc: func(domain string) (endpoints []*models.Endpoint, err error) {
+				return []*models.Endpoint{{URL: serv.URL}}, nil
+			}}
+
+		err := v.DeactivateDID("did:trustbloc:testnet:123", deactivateRevealValue, WithDeactivateSigningKeyID("recovery"))
+		require.NoError(t, err)
+		require.Equal(t, "deactivate", deactivateRequest["type"])
+		require.Equal(t, "123", deactivateRequest["did_suffix"])
+	})
+}
+
 func discoveryMock(endpoints []*models.Endpoint, err error) *mockdiscovery.MockDiscoveryService {
 	return &mockdiscovery.MockDiscoveryService{
 		GetEndpointsFunc: func(string) ([]*models.Endpoint, error) {
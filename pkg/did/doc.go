@@ -9,10 +9,15 @@ import (
 	"crypto/ecdsa"
 	"crypto/ed25519"
 	"crypto/elliptic"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcutil/base58"
 	docdid "github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/trustbloc/sidetree-core-go/pkg/document"
+	"github.com/trustbloc/sidetree-core-go/pkg/docutil"
 	"github.com/trustbloc/sidetree-core-go/pkg/jws"
 	"github.com/trustbloc/sidetree-core-go/pkg/util/pubkey"
 )
@@ -30,6 +35,8 @@ const (
 
 	// PublicKeyEncodingJwk define jwk encoding type
 	PublicKeyEncodingJwk = "Jwk"
+	// PublicKeyEncodingBase58 define base58 encoding type (publicKeyBase58)
+	PublicKeyEncodingBase58 = "Base58"
 
 	// KeyUsageOps defines key usage as operations key
 	KeyUsageOps = "ops"
@@ -37,6 +44,8 @@ const (
 	KeyUsageAuth = "auth"
 	// KeyUsageAssertion defines key usage as assertion key
 	KeyUsageAssertion = "assertion"
+	// KeyUsageAgreement defines key usage as agreement key
+	KeyUsageAgreement = "agreement"
 	// KeyUsageDelegation defines key usage as delegation key
 	KeyUsageDelegation = "delegation"
 	// KeyUsageInvocation defines key usage as invocation key
@@ -50,13 +59,32 @@ const (
 	// Ed25519VerificationKey2018 define key type signature
 	Ed25519VerificationKey2018 = "Ed25519VerificationKey2018"
 
+	// EcdsaSecp256k1VerificationKey2019 define key type signature
+	EcdsaSecp256k1VerificationKey2019 = "EcdsaSecp256k1VerificationKey2019"
+
+	// X25519KeyAgreementKey2019 define key type agreement
+	X25519KeyAgreementKey2019 = "X25519KeyAgreementKey2019"
+
 	// Ed25519KeyType defines ed25119 key type
 	Ed25519KeyType = "Ed25519"
 
 	// P256KeyType EC P-256 key type
 	P256KeyType = "P256"
+
+	// Secp256k1KeyType EC secp256k1 key type
+	Secp256k1KeyType = "secp256k1"
+
+	// X25519KeyType X25519 key agreement key type
+	X25519KeyType = "X25519"
+
+	x25519PublicKeySize = 32
 )
 
+// keyProperties are the did document properties listing public keys, either embedded or by reference
+var keyProperties = []string{document.PublicKeyProperty, document.AuthenticationProperty, //nolint:gochecknoglobals
+	document.AssertionMethodProperty, document.AgreementKeyProperty, document.DelegationKeyProperty,
+	document.InvocationKeyProperty}
+
 type rawDoc struct {
 	PublicKey []map[string]interface{} `json:"publicKey,omitempty"`
 	Service   []map[string]interface{} `json:"service,omitempty"`
@@ -123,35 +151,123 @@ func populateRawPublicKey(pk *PublicKey) (map[string]interface{}, error) {
 	rawPK[jsonldType] = pk.Type
 	rawPK[jsonldUsage] = pk.Usage
 
+	if err := validateEncoding(pk); err != nil {
+		return nil, err
+	}
+
+	jwk, err := publicKeyJWK(pk.KeyType, pk.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	rawPK[jsonldPublicKeyjwk] = jwk
+
+	return rawPK, nil
+}
+
+// validateEncoding checks that the resolved document expresses the public key in its encoding.
+// Sidetree only accepts jwk public keys in operations, the representation in the resolved document is chosen
+// from the key type: publicKeyBase58 for Ed25519VerificationKey2018 and X25519KeyAgreementKey2019 keys,
+// publicKeyJwk otherwise.
+func validateEncoding(pk *PublicKey) error {
 	switch pk.Encoding {
 	case PublicKeyEncodingJwk:
-		var jwk *jws.JWK
+		return nil
+	case PublicKeyEncodingBase58:
+		if pk.Type == Ed25519VerificationKey2018 || pk.Type == X25519KeyAgreementKey2019 {
+			return nil
+		}
 
-		var err error
+		return fmt.Errorf("%s encoding is only supported for %s and %s public keys", pk.Encoding,
+			Ed25519VerificationKey2018, X25519KeyAgreementKey2019)
+	default:
+		return fmt.Errorf("public key encoding not supported: %s", pk.Encoding)
+	}
+}
 
-		switch pk.KeyType {
-		case Ed25519KeyType:
-			jwk, err = pubkey.GetPublicKeyJWK(ed25519.PublicKey(pk.Value))
-			if err != nil {
-				return nil, err
+// publicKeyJWK converts the raw public key of the given key type to JWK.
+// EC keys are expected in uncompressed form (as returned by elliptic.Marshal); secp256k1 keys may also be compressed.
+func publicKeyJWK(keyType string, value []byte) (*jws.JWK, error) {
+	switch keyType {
+	case Ed25519KeyType:
+		if len(value) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid %s public key size: %d", keyType, len(value))
+		}
+
+		return pubkey.GetPublicKeyJWK(ed25519.PublicKey(value))
+	case P256KeyType:
+		x, y := elliptic.Unmarshal(elliptic.P256(), value)
+		if x == nil {
+			return nil, fmt.Errorf("invalid %s public key", keyType)
+		}
+
+		return pubkey.GetPublicKeyJWK(&ecdsa.PublicKey{X: x, Y: y, Curve: elliptic.P256()})
+	case Secp256k1KeyType:
+		key, err := btcec.ParsePubKey(value, btcec.S256())
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s public key: %w", keyType, err)
+		}
+
+		return pubkey.GetPublicKeyJWK(key.ToECDSA())
+	case X25519KeyType:
+		if len(value) != x25519PublicKeySize {
+			return nil, fmt.Errorf("invalid %s public key size: %d", keyType, len(value))
+		}
+
+		// the node publishes the jwk as is, ParseDocument converts it to publicKeyBase58
+		return &jws.JWK{Kty: "OKP", Crv: X25519KeyType, X: base64.RawURLEncoding.EncodeToString(value)}, nil
+	default:
+		return nil, fmt.Errorf("invalid key type: %s", keyType)
+	}
+}
+
+// ParseDocument parses a did document built by a sidetree node. The node publishes X25519 key agreement keys
+// as JWKs, which aries can't parse, they are converted to the publicKeyBase58 representation of their key type.
+func ParseDocument(data []byte) (*docdid.Doc, error) {
+	var raw map[string]interface{}
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal did document: %w", err)
+	}
+
+	converted := false
+
+	for _, property := range keyProperties {
+		keys, _ := raw[property].([]interface{}) //nolint:errcheck
+
+		for _, key := range keys {
+			pk, ok := key.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			jwk, ok := pk[document.PublicKeyJwkProperty].(map[string]interface{})
+			if !ok || jwk["crv"] != X25519KeyType {
+				continue
 			}
-		case P256KeyType:
-			x, y := elliptic.Unmarshal(elliptic.P256(), pk.Value)
 
-			jwk, err = pubkey.GetPublicKeyJWK(&ecdsa.PublicKey{X: x, Y: y, Curve: elliptic.P256()})
+			x, err := docutil.DecodeString(fmt.Sprint(jwk["x"]))
 			if err != nil {
-				return nil, err
+				return nil, fmt.Errorf("invalid %s jwk x: %w", X25519KeyType, err)
 			}
-		default:
-			return nil, fmt.Errorf("invalid key type: %s", pk.KeyType)
+
+			delete(pk, document.PublicKeyJwkProperty)
+			pk[document.PublicKeyBase58Property] = base58.Encode(x)
+
+			converted = true
 		}
+	}
 
-		rawPK[jsonldPublicKeyjwk] = jwk
-	default:
-		return nil, fmt.Errorf("public key encoding not supported: %s", pk.Encoding)
+	if converted {
+		var err error
+
+		data, err = json.Marshal(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal did document: %w", err)
+		}
 	}
 
-	return rawPK, nil
+	return docdid.ParseDocument(data)
 }
 
 func populateRawServices(services []docdid.Service) []map[string]interface{} {
@@ -0,0 +1,195 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package did
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DIDMethod is the trustbloc did method name
+	DIDMethod = "trustbloc"
+	// InitialStateParam is the did parameter holding the initial state of long-form dids
+	InitialStateParam = "-trustbloc-initial-state"
+
+	didPrefix       = "did:" + DIDMethod + ":"
+	portSeparator   = "%3A"
+	maxHostLength   = 253
+	maxLabelLength  = 63
+	maxPort         = 65535
+	didPartsShort   = 2
+	didPartsNetwork = 3
+)
+
+// ErrInvalidDID is returned by ParseDID for identifiers that don't match the did:trustbloc syntax
+var ErrInvalidDID = errors.New("invalid did") //nolint:gochecknoglobals
+
+// DID holds the components of a did:trustbloc identifier, which has the syntax:
+//
+//	did-trustbloc     = "did:trustbloc:" consortium-domain [ ":" network ] ":" unique-suffix
+//	                    [ "?-trustbloc-initial-state=" initial-state ]
+//	consortium-domain = hostname [ "%3A" port ]
+//	network           = 1*idchar
+//	unique-suffix     = 1*idchar
+//	initial-state     = 1*idchar
+//	idchar            = ALPHA / DIGIT / "." / "-" / "_"
+type DID struct {
+	// Domain is the consortium domain, as host:port if the did has a port
+	Domain string
+	// Network is the optional network namespace of the consortium
+	Network string
+	// Suffix is the sidetree unique suffix
+	Suffix string
+	// InitialState is the encoded initial state of a long-form did, empty for short-form dids
+	InitialState string
+}
+
+// ParseDID validates a did:trustbloc identifier and returns its components.
+// The errors returned wrap ErrInvalidDID.
+func ParseDID(did string) (*DID, error) {
+	invalid := func(reason string) error {
+		return fmt.Errorf("%w %s: %s", ErrInvalidDID, did, reason)
+	}
+
+	if !strings.HasPrefix(did, didPrefix) {
+		return nil, invalid("expected did:" + DIDMethod + " method")
+	}
+
+	parsed := &DID{}
+	id := did[len(didPrefix):]
+
+	if pos := strings.Index(id, "?"); pos != -1 {
+		query := id[pos+1:]
+		id = id[:pos]
+
+		if !strings.HasPrefix(query, InitialStateParam+"=") {
+			return nil, invalid("unsupported did parameter")
+		}
+
+		parsed.InitialState = query[len(InitialStateParam)+1:]
+
+		switch {
+		case parsed.InitialState == "":
+			return nil, invalid("initial state is empty")
+		case !isIDChars(parsed.InitialState):
+			return nil, invalid("invalid initial state")
+		}
+	}
+
+	parts := strings.Split(id, ":")
+
+	switch len(parts) {
+	case didPartsShort:
+		parsed.Suffix = parts[1]
+	case didPartsNetwork:
+		parsed.Network, parsed.Suffix = parts[1], parts[2]
+
+		if !isIDChars(parsed.Network) {
+			return nil, invalid("invalid network")
+		}
+	default:
+		return nil, invalid("expected did:" + DIDMethod + ":<consortium-domain>[:<network>]:<unique-suffix>")
+	}
+
+	if !isIDChars(parsed.Suffix) {
+		return nil, invalid("invalid unique suffix")
+	}
+
+	domain, err := parseDomain(parts[0])
+	if err != nil {
+		return nil, invalid(err.Error())
+	}
+
+	parsed.Domain = domain
+
+	return parsed, nil
+}
+
+// ShortForm returns the did without the initial state
+func (d *DID) ShortForm() string {
+	parts := []string{strings.Replace(d.Domain, ":", portSeparator, 1)}
+
+	if d.Network != "" {
+		parts = append(parts, d.Network)
+	}
+
+	return didPrefix + strings.Join(append(parts, d.Suffix), ":")
+}
+
+// String returns the did, with the initial state for long-form dids
+func (d *DID) String() string {
+	if d.InitialState == "" {
+		return d.ShortForm()
+	}
+
+	return d.ShortForm() + "?" + InitialStateParam + "=" + d.InitialState
+}
+
+// parseDomain validates the consortium domain and returns it as host:port if it has a port
+func parseDomain(domain string) (string, error) {
+	host, port := domain, ""
+
+	if pos := strings.Index(domain, portSeparator); pos != -1 {
+		host, port = domain[:pos], domain[pos+len(portSeparator):]
+
+		n, err := strconv.Atoi(port)
+		if err != nil || n < 1 || n > maxPort || strings.HasPrefix(port, "+") {
+			return "", fmt.Errorf("invalid consortium domain port %s", port)
+		}
+	}
+
+	if !isHostname(host) {
+		return "", fmt.Errorf("invalid consortium domain %s", host)
+	}
+
+	if port == "" {
+		return host, nil
+	}
+
+	return host + ":" + port, nil
+}
+
+// isHostname reports whether host is a valid hostname (RFC 1123)
+func isHostname(host string) bool {
+	if host == "" || len(host) > maxHostLength {
+		return false
+	}
+
+	for _, label := range strings.Split(host, ".") {
+		if label == "" || len(label) > maxLabelLength || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+
+		for _, c := range label {
+			if !isAlphaNum(c) && c != '-' {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func isIDChars(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, c := range s {
+		if !isAlphaNum(c) && c != '.' && c != '-' && c != '_' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isAlphaNum(c rune) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
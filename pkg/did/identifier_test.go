@@ -0,0 +1,77 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package did
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDID(t *testing.T) {
+	t.Run("test short form", func(t *testing.T) {
+		parsed, err := ParseDID("did:trustbloc:testnet.trustbloc.local:EiAB_-1.x")
+		require.NoError(t, err)
+		require.Equal(t, &DID{Domain: "testnet.trustbloc.local", Suffix: "EiAB_-1.x"}, parsed)
+		require.Equal(t, "did:trustbloc:testnet.trustbloc.local:EiAB_-1.x", parsed.String())
+	})
+
+	t.Run("test network segment", func(t *testing.T) {
+		parsed, err := ParseDID("did:trustbloc:testnet.trustbloc.local:staging:123")
+		require.NoError(t, err)
+		require.Equal(t, "testnet.trustbloc.local", parsed.Domain)
+		require.Equal(t, "staging", parsed.Network)
+		require.Equal(t, "123", parsed.Suffix)
+		require.Equal(t, "did:trustbloc:testnet.trustbloc.local:staging:123", parsed.ShortForm())
+	})
+
+	t.Run("test domain with port", func(t *testing.T) {
+		parsed, err := ParseDID("did:trustbloc:localhost%3A8080:123")
+		require.NoError(t, err)
+		require.Equal(t, "localhost:8080", parsed.Domain)
+		require.Equal(t, "did:trustbloc:localhost%3A8080:123", parsed.String())
+	})
+
+	t.Run("test long form", func(t *testing.T) {
+		longForm := "did:trustbloc:testnet:123?-trustbloc-initial-state=abc.def"
+
+		parsed, err := ParseDID(longForm)
+		require.NoError(t, err)
+		require.Equal(t, "abc.def", parsed.InitialState)
+		require.Equal(t, "did:trustbloc:testnet:123", parsed.ShortForm())
+		require.Equal(t, longForm, parsed.String())
+	})
+
+	t.Run("test invalid dids", func(t *testing.T) {
+		tests := map[string]string{
+			"did:1223":                                               "expected did:trustbloc method",
+			"did:example:testnet:123":                                "expected did:trustbloc method",
+			"did:trustbloc:testnet":                                  "expected did:trustbloc:<consortium-domain>",
+			"did:trustbloc:a:b:c:d":                                  "expected did:trustbloc:<consortium-domain>",
+			"did:trustbloc:testnet:12$3":                             "invalid unique suffix",
+			"did:trustbloc:testnet:":                                 "invalid unique suffix",
+			"did:trustbloc:testnet:net/1:123":                        "invalid network",
+			"did:trustbloc:-testnet:123":                             "invalid consortium domain -testnet",
+			"did:trustbloc:test..net:123":                            "invalid consortium domain test..net",
+			"did:trustbloc:test_net:123":                             "invalid consortium domain test_net",
+			"did:trustbloc:testnet%3Aabc:123":                        "invalid consortium domain port abc",
+			"did:trustbloc:testnet%3A70000:123":                      "invalid consortium domain port 70000",
+			"did:trustbloc:testnet:123?service=agent":                "unsupported did parameter",
+			"did:trustbloc:testnet:123?-trustbloc-initial-state=":    "initial state is empty",
+			"did:trustbloc:testnet:123?-trustbloc-initial-state=a b": "invalid initial state",
+		}
+
+		for did, reason := range tests {
+			parsed, err := ParseDID(did)
+			require.Error(t, err, did)
+			require.True(t, errors.Is(err, ErrInvalidDID))
+			require.Contains(t, err.Error(), "invalid did "+did)
+			require.Contains(t, err.Error(), reason)
+			require.Nil(t, parsed)
+		}
+	})
+}
@@ -0,0 +1,70 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package did
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/trustbloc/sidetree-core-go/pkg/docutil"
+	"github.com/trustbloc/sidetree-core-go/pkg/restapi/model"
+)
+
+// ComputedDID holds the identifiers a sidetree node assigns to a did created from the same options
+type ComputedDID struct {
+	// ShortForm is the did:trustbloc:<consortium>:<suffix> did, resolvable once the create operation is anchored
+	ShortForm string
+	// LongForm is the short-form did with the encoded initial state, resolvable before anchoring
+	LongForm string
+	// UpdateRevealValue is the reveal value for the first update operation
+	UpdateRevealValue []byte
+	// RecoveryRevealValue is the reveal value for the first recover or deactivate operation
+	RecoveryRevealValue []byte
+}
+
+// ComputeDID computes the short-form and long-form did for the given create options, without contacting
+// any endpoint. The reveal values are part of the initial state: the ones generated when they are not
+// supplied are returned, and have to be passed to CreateDID to get the same did.
+func (c *Client) ComputeDID(domain string, opts ...CreateDIDOption) (*ComputedDID, error) {
+	createDIDOpts, err := newCreateDIDOpts(domain, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := c.buildSideTreeRequest(createDIDOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sidetree request: %w", err)
+	}
+
+	shortForm, longForm, err := computeDID(domain, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ComputedDID{
+		ShortForm:           shortForm,
+		LongForm:            longForm,
+		UpdateRevealValue:   createDIDOpts.nextUpdateRevealValue,
+		RecoveryRevealValue: createDIDOpts.nextRecoveryRevealValue,
+	}, nil
+}
+
+// computeDID returns the short-form and long-form did for a sidetree create request
+func computeDID(domain string, req []byte) (string, string, error) {
+	var createRequest model.CreateRequest
+	if err := json.Unmarshal(req, &createRequest); err != nil {
+		return "", "", fmt.Errorf("failed to unmarshal create request: %w", err)
+	}
+
+	suffix, err := docutil.CalculateUniqueSuffix(createRequest.SuffixData, sha2_256)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to calculate unique suffix: %w", err)
+	}
+
+	did := &DID{Domain: domain, Suffix: suffix, InitialState: createRequest.SuffixData + "." + createRequest.Delta}
+
+	return did.ShortForm(), did.String(), nil
+}
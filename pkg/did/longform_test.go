@@ -0,0 +1,110 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package did
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/docutil"
+	"github.com/trustbloc/sidetree-core-go/pkg/restapi/model"
+
+	mockendpoint "github.com/trustbloc/trustbloc-did-method/pkg/internal/mock/endpoint"
+	"github.com/trustbloc/trustbloc-did-method/pkg/vdri/trustbloc/models"
+)
+
+func TestClient_ComputeDID(t *testing.T) {
+	pubKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	recoveryKey := WithPublicKey(&PublicKey{ID: "key1", Type: JWSVerificationKey2020, Encoding: PublicKeyEncodingJwk,
+		Value: pubKey, KeyType: Ed25519KeyType, Usage: []string{KeyUsageGeneral}, Recovery: true})
+
+	t.Run("test domain is empty", func(t *testing.T) {
+		v := New()
+
+		computed, err := v.ComputeDID("")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "domain is empty")
+		require.Nil(t, computed)
+	})
+
+	t.Run("test error from build sidetree request", func(t *testing.T) {
+		v := New()
+
+		computed, err := v.ComputeDID("testnet")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "recovery key not found")
+		require.Nil(t, computed)
+	})
+
+	t.Run("test error from parse create request", func(t *testing.T) {
+		_, _, err := computeDID("testnet", []byte("{"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to unmarshal create request")
+	})
+
+	t.Run("test success", func(t *testing.T) {
+		v := New()
+
+		computed, err := v.ComputeDID("testnet", recoveryKey)
+		require.NoError(t, err)
+		require.Len(t, computed.UpdateRevealValue, revealValueLength)
+		require.Len(t, computed.RecoveryRevealValue, revealValueLength)
+		require.True(t, strings.HasPrefix(computed.ShortForm, "did:trustbloc:testnet:"))
+		require.True(t, strings.HasPrefix(computed.LongForm, computed.ShortForm+"?-trustbloc-initial-state="))
+
+		// same options and reveal values produce the same did
+		again, err := v.ComputeDID("testnet", recoveryKey,
+			WithInitialUpdateRevealValue(computed.UpdateRevealValue),
+			WithInitialRecoveryRevealValue(computed.RecoveryRevealValue))
+		require.NoError(t, err)
+		require.Equal(t, computed, again)
+	})
+
+	t.Run("test matches create request", func(t *testing.T) {
+		var createRequest model.CreateRequest
+
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&createRequest))
+
+			bytes, err := (&did.Doc{ID: "did1", Context: []string{did.Context}}).JSONBytes()
+			require.NoError(t, err)
+			_, err = fmt.Fprint(w, string(bytes))
+			require.NoError(t, err)
+		}))
+		defer serv.Close()
+
+		v := New()
+
+		v.endpointService = &mockendpoint.MockEndpointService{
+			GetEndpointsFunc: func(domain string) (endpoints []*models.Endpoint, err error) {
+				return []*models.Endpoint{{URL: serv.URL}}, nil
+			}}
+
+		computed, err := v.ComputeDID("testnet", recoveryKey)
+		require.NoError(t, err)
+
+		_, err = v.CreateDID("testnet", recoveryKey,
+			WithInitialUpdateRevealValue(computed.UpdateRevealValue),
+			WithInitialRecoveryRevealValue(computed.RecoveryRevealValue))
+		require.NoError(t, err)
+
+		suffix, err := docutil.CalculateUniqueSuffix(createRequest.SuffixData, sha2_256)
+		require.NoError(t, err)
+		require.Equal(t, "did:trustbloc:testnet:"+suffix, computed.ShortForm)
+		require.Equal(t, computed.ShortForm+"?-trustbloc-initial-state="+createRequest.SuffixData+"."+
+			createRequest.Delta, computed.LongForm)
+	})
+}
@@ -0,0 +1,257 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package did
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"fmt"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/hyperledger/aries-framework-go/pkg/kms"
+	"github.com/trustbloc/sidetree-core-go/pkg/jws"
+	"github.com/trustbloc/sidetree-core-go/pkg/util/ecsigner"
+	"github.com/trustbloc/sidetree-core-go/pkg/util/edsigner"
+	"github.com/trustbloc/sidetree-core-go/pkg/util/pubkey"
+)
+
+const (
+	edAlgorithm        = "EdDSA"
+	ecAlgorithm        = "ES256"
+	secp256k1Algorithm = "ES256K"
+)
+
+// Signer signs sidetree operations with the private keys it manages
+type Signer interface {
+	// Sign signs data with the private key identified by keyID
+	Sign(keyID string, data []byte) ([]byte, error)
+	// Algorithm returns the JWS algorithm of the key identified by keyID
+	Algorithm(keyID string) (string, error)
+	// PublicKeyJWK returns the public key of the key identified by keyID in JWK format
+	PublicKeyJWK(keyID string) (*jws.JWK, error)
+}
+
+// InMemorySigner is a Signer for ed25519, P-256 and secp256k1 private keys held in memory
+type InMemorySigner struct {
+	mutex sync.RWMutex
+	keys  map[string]crypto.PrivateKey
+}
+
+// NewInMemorySigner returns new in-memory signer
+func NewInMemorySigner() *InMemorySigner {
+	return &InMemorySigner{keys: make(map[string]crypto.PrivateKey)}
+}
+
+// AddKey adds a private key under the given key ID
+func (s *InMemorySigner) AddKey(keyID string, privateKey crypto.PrivateKey) error {
+	switch key := privateKey.(type) {
+	case ed25519.PrivateKey:
+	case *ecdsa.PrivateKey:
+		if key.Curve != elliptic.P256() && key.Curve != btcec.S256() {
+			return fmt.Errorf("curve not supported: %s", key.Curve.Params().Name)
+		}
+	default:
+		return fmt.Errorf("private key type not supported: %T", privateKey)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.keys[keyID] = privateKey
+
+	return nil
+}
+
+// Sign signs data with the private key identified by keyID
+func (s *InMemorySigner) Sign(keyID string, data []byte) ([]byte, error) {
+	privateKey, err := s.getKey(keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := privateKey.(type) {
+	case ed25519.PrivateKey:
+		return edsigner.New(key, edAlgorithm, "").Sign(data)
+	default:
+		// the algorithm is only used for the headers, the hash is chosen by curve
+		return ecsigner.New(key.(*ecdsa.PrivateKey), ecAlgorithm, "").Sign(data)
+	}
+}
+
+// Algorithm returns the JWS algorithm of the key identified by keyID
+func (s *InMemorySigner) Algorithm(keyID string) (string, error) {
+	privateKey, err := s.getKey(keyID)
+	if err != nil {
+		return "", err
+	}
+
+	switch key := privateKey.(type) {
+	case ed25519.PrivateKey:
+		return edAlgorithm, nil
+	default:
+		if key.(*ecdsa.PrivateKey).Curve == btcec.S256() {
+			return secp256k1Algorithm, nil
+		}
+
+		return ecAlgorithm, nil
+	}
+}
+
+// PublicKeyJWK returns the public key of the key identified by keyID in JWK format
+func (s *InMemorySigner) PublicKeyJWK(keyID string) (*jws.JWK, error) {
+	privateKey, err := s.getKey(keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := privateKey.(type) {
+	case ed25519.PrivateKey:
+		return pubkey.GetPublicKeyJWK(key.Public())
+	default:
+		return pubkey.GetPublicKeyJWK(&key.(*ecdsa.PrivateKey).PublicKey)
+	}
+}
+
+func (s *InMemorySigner) getKey(keyID string) (crypto.PrivateKey, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	privateKey, ok := s.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("key not found: %s", keyID)
+	}
+
+	return privateKey, nil
+}
+
+type keyManager interface {
+	Get(keyID string) (interface{}, error)
+	ExportPubKeyBytes(keyID string) ([]byte, error)
+}
+
+type signingCrypto interface {
+	Sign(msg []byte, kh interface{}) ([]byte, error)
+}
+
+// KMSSigner is a Signer backed by an aries-framework-go key manager (such as localkms) and crypto service
+type KMSSigner struct {
+	keyManager keyManager
+	crypto     signingCrypto
+	mutex      sync.RWMutex
+	keyTypes   map[string]kms.KeyType
+}
+
+// NewKMSSigner returns new KMS signer
+func NewKMSSigner(keyManager keyManager, crypto signingCrypto) *KMSSigner {
+	return &KMSSigner{keyManager: keyManager, crypto: crypto, keyTypes: make(map[string]kms.KeyType)}
+}
+
+// AddKey registers the type of a key stored in the KMS, so it can be used for signing.
+// Only kms.ED25519Type and kms.ECDSAP256TypeIEEEP1363 keys produce JWS compatible signatures.
+func (s *KMSSigner) AddKey(keyID string, keyType kms.KeyType) error {
+	if keyType != kms.ED25519Type && keyType != kms.ECDSAP256TypeIEEEP1363 {
+		return fmt.Errorf("kms key type not supported: %s", keyType)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.keyTypes[keyID] = keyType
+
+	return nil
+}
+
+// Sign signs data with the KMS key identified by keyID
+func (s *KMSSigner) Sign(keyID string, data []byte) ([]byte, error) {
+	if _, err := s.getKeyType(keyID); err != nil {
+		return nil, err
+	}
+
+	kh, err := s.keyManager.Get(keyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kms key handle: %w", err)
+	}
+
+	return s.crypto.Sign(data, kh)
+}
+
+// Algorithm returns the JWS algorithm of the KMS key identified by keyID
+func (s *KMSSigner) Algorithm(keyID string) (string, error) {
+	keyType, err := s.getKeyType(keyID)
+	if err != nil {
+		return "", err
+	}
+
+	if keyType == kms.ED25519Type {
+		return edAlgorithm, nil
+	}
+
+	return ecAlgorithm, nil
+}
+
+// PublicKeyJWK returns the public key of the KMS key identified by keyID in JWK format
+func (s *KMSSigner) PublicKeyJWK(keyID string) (*jws.JWK, error) {
+	keyType, err := s.getKeyType(keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	pubKeyBytes, err := s.keyManager.ExportPubKeyBytes(keyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export kms public key: %w", err)
+	}
+
+	if keyType == kms.ED25519Type {
+		return pubkey.GetPublicKeyJWK(ed25519.PublicKey(pubKeyBytes))
+	}
+
+	x, y := elliptic.Unmarshal(elliptic.P256(), pubKeyBytes)
+	if x == nil {
+		return nil, fmt.Errorf("invalid P-256 public key exported for key %s", keyID)
+	}
+
+	return pubkey.GetPublicKeyJWK(&ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y})
+}
+
+func (s *KMSSigner) getKeyType(keyID string) (kms.KeyType, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	keyType, ok := s.keyTypes[keyID]
+	if !ok {
+		return "", fmt.Errorf("key not found: %s", keyID)
+	}
+
+	return keyType, nil
+}
+
+// sidetreeSigner signs sidetree requests with a Signer key
+type sidetreeSigner struct {
+	signer Signer
+	keyID  string
+	alg    string
+	kid    string
+}
+
+// Sign signs data with the Signer key
+func (s *sidetreeSigner) Sign(data []byte) ([]byte, error) {
+	return s.signer.Sign(s.keyID, data)
+}
+
+// Headers provides the JWS protected headers for the Signer key
+func (s *sidetreeSigner) Headers() jws.Headers {
+	headers := make(jws.Headers)
+	headers[jws.HeaderAlgorithm] = s.alg
+
+	if s.kid != "" {
+		headers[jws.HeaderKeyID] = s.kid
+	}
+
+	return headers
+}
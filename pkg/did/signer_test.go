@@ -0,0 +1,209 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package did
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/hyperledger/aries-framework-go/pkg/crypto/tinkcrypto"
+	"github.com/hyperledger/aries-framework-go/pkg/kms"
+	"github.com/hyperledger/aries-framework-go/pkg/kms/localkms"
+	mockkms "github.com/hyperledger/aries-framework-go/pkg/mock/kms"
+	mockstorage "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock/noop"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemorySigner(t *testing.T) {
+	t.Run("test add key error", func(t *testing.T) {
+		s := NewInMemorySigner()
+
+		err := s.AddKey("k1", "wrong")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "private key type not supported")
+
+		ecPrivKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+		require.NoError(t, err)
+
+		err = s.AddKey("k1", ecPrivKey)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "curve not supported")
+	})
+
+	t.Run("test key not found", func(t *testing.T) {
+		s := NewInMemorySigner()
+
+		_, err := s.Sign("k1", []byte("data"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "key not found: k1")
+
+		_, err = s.Algorithm("k1")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "key not found: k1")
+
+		_, err = s.PublicKeyJWK("k1")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "key not found: k1")
+	})
+
+	t.Run("test success", func(t *testing.T) {
+		s := NewInMemorySigner()
+
+		pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		require.NoError(t, s.AddKey("ed", privKey))
+
+		ecPrivKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		require.NoError(t, s.AddKey("ec", ecPrivKey))
+
+		alg, err := s.Algorithm("ed")
+		require.NoError(t, err)
+		require.Equal(t, edAlgorithm, alg)
+
+		signature, err := s.Sign("ed", []byte("data"))
+		require.NoError(t, err)
+		require.True(t, ed25519.Verify(pubKey, []byte("data"), signature))
+
+		jwk, err := s.PublicKeyJWK("ed")
+		require.NoError(t, err)
+		require.Equal(t, "Ed25519", jwk.Crv)
+
+		alg, err = s.Algorithm("ec")
+		require.NoError(t, err)
+		require.Equal(t, ecAlgorithm, alg)
+
+		signature, err = s.Sign("ec", []byte("data"))
+		require.NoError(t, err)
+		require.True(t, verifyP1363(&ecPrivKey.PublicKey, []byte("data"), signature))
+
+		jwk, err = s.PublicKeyJWK("ec")
+		require.NoError(t, err)
+		require.Equal(t, "P-256", jwk.Crv)
+
+		secp256k1PrivKey, err := btcec.NewPrivateKey(btcec.S256())
+		require.NoError(t, err)
+		require.NoError(t, s.AddKey("secp256k1", secp256k1PrivKey.ToECDSA()))
+
+		alg, err = s.Algorithm("secp256k1")
+		require.NoError(t, err)
+		require.Equal(t, secp256k1Algorithm, alg)
+
+		_, err = s.Sign("secp256k1", []byte("data"))
+		require.NoError(t, err)
+
+		jwk, err = s.PublicKeyJWK("secp256k1")
+		require.NoError(t, err)
+		require.Equal(t, "secp256k1", jwk.Crv)
+	})
+}
+
+func TestKMSSigner(t *testing.T) {
+	keyManager, err := localkms.New("local-lock://custom/master/key/",
+		mockkms.NewProvider(mockstorage.NewMockStoreProvider(), &noop.NoLock{}))
+	require.NoError(t, err)
+
+	crypto, err := tinkcrypto.New()
+	require.NoError(t, err)
+
+	t.Run("test add key error", func(t *testing.T) {
+		s := NewKMSSigner(keyManager, crypto)
+
+		err := s.AddKey("k1", kms.ECDSAP256TypeDER)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "kms key type not supported")
+	})
+
+	t.Run("test key not found", func(t *testing.T) {
+		s := NewKMSSigner(keyManager, crypto)
+
+		_, err := s.Sign("k1", []byte("data"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "key not found: k1")
+
+		_, err = s.Algorithm("k1")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "key not found: k1")
+
+		_, err = s.PublicKeyJWK("k1")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "key not found: k1")
+	})
+
+	t.Run("test kms error", func(t *testing.T) {
+		s := NewKMSSigner(keyManager, crypto)
+		require.NoError(t, s.AddKey("k1", kms.ED25519Type))
+
+		_, err := s.Sign("k1", []byte("data"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to get kms key handle")
+
+		_, err = s.PublicKeyJWK("k1")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to export kms public key")
+	})
+
+	t.Run("test success", func(t *testing.T) {
+		s := NewKMSSigner(keyManager, crypto)
+
+		edKeyID, _, err := keyManager.Create(kms.ED25519Type)
+		require.NoError(t, err)
+		require.NoError(t, s.AddKey(edKeyID, kms.ED25519Type))
+
+		ecKeyID, _, err := keyManager.Create(kms.ECDSAP256TypeIEEEP1363)
+		require.NoError(t, err)
+		require.NoError(t, s.AddKey(ecKeyID, kms.ECDSAP256TypeIEEEP1363))
+
+		alg, err := s.Algorithm(edKeyID)
+		require.NoError(t, err)
+		require.Equal(t, edAlgorithm, alg)
+
+		signature, err := s.Sign(edKeyID, []byte("data"))
+		require.NoError(t, err)
+
+		pubKeyBytes, err := keyManager.ExportPubKeyBytes(edKeyID)
+		require.NoError(t, err)
+		require.True(t, ed25519.Verify(pubKeyBytes, []byte("data"), signature))
+
+		jwk, err := s.PublicKeyJWK(edKeyID)
+		require.NoError(t, err)
+		require.Equal(t, "Ed25519", jwk.Crv)
+
+		alg, err = s.Algorithm(ecKeyID)
+		require.NoError(t, err)
+		require.Equal(t, ecAlgorithm, alg)
+
+		signature, err = s.Sign(ecKeyID, []byte("data"))
+		require.NoError(t, err)
+
+		jwk, err = s.PublicKeyJWK(ecKeyID)
+		require.NoError(t, err)
+		require.Equal(t, "P-256", jwk.Crv)
+
+		pubKeyBytes, err = keyManager.ExportPubKeyBytes(ecKeyID)
+		require.NoError(t, err)
+
+		x, y := elliptic.Unmarshal(elliptic.P256(), pubKeyBytes)
+		require.True(t, verifyP1363(&ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, []byte("data"), signature))
+	})
+}
+
+func verifyP1363(pubKey *ecdsa.PublicKey, data, signature []byte) bool {
+	if len(signature) != 64 {
+		return false
+	}
+
+	hash := sha256.Sum256(data)
+
+	return ecdsa.Verify(pubKey, hash[:], new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:]))
+}
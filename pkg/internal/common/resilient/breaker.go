@@ -0,0 +1,87 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package resilient
+
+import (
+	"sync"
+	"time"
+)
+
+// circuitBreaker tracks the consecutive failures of each endpoint. An endpoint is skipped for the open timeout
+// once it reaches the failure threshold, then a single request is let through to probe it: the circuit is
+// closed again if it succeeds and stays open for another open timeout if it fails.
+type circuitBreaker struct {
+	mu          sync.Mutex
+	threshold   int
+	openTimeout time.Duration
+	now         func() time.Time
+	circuits    map[string]*circuit
+}
+
+type circuit struct {
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func newCircuitBreaker(threshold int, openTimeout time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, openTimeout: openTimeout, now: time.Now,
+		circuits: make(map[string]*circuit)}
+}
+
+// allow reports whether a request can be sent to the endpoint
+func (b *circuitBreaker) allow(endpoint string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[endpoint]
+	if !ok || c.failures < b.threshold {
+		return true
+	}
+
+	if b.now().Before(c.openUntil) || c.probing {
+		return false
+	}
+
+	c.probing = true
+
+	return true
+}
+
+// record records the outcome of a request sent to the endpoint
+func (b *circuitBreaker) record(endpoint string, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failed {
+		delete(b.circuits, endpoint)
+
+		return
+	}
+
+	c, ok := b.circuits[endpoint]
+	if !ok {
+		c = &circuit{}
+		b.circuits[endpoint] = c
+	}
+
+	c.failures++
+	c.probing = false
+
+	if c.failures >= b.threshold {
+		c.openUntil = b.now().Add(b.openTimeout)
+	}
+}
+
+// cancel releases the probe of the endpoint when a request is cancelled before its outcome is known
+func (b *circuitBreaker) cancel(endpoint string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if c, ok := b.circuits[endpoint]; ok {
+		c.probing = false
+	}
+}
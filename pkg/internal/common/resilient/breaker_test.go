@@ -0,0 +1,55 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package resilient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+
+	b := newCircuitBreaker(2, time.Minute)
+	b.now = func() time.Time { return now }
+
+	t.Run("test opens after consecutive failures", func(t *testing.T) {
+		b.record("a", true)
+		require.True(t, b.allow("a"))
+
+		b.record("a", false)
+		b.record("a", true)
+		require.True(t, b.allow("a"))
+
+		b.record("a", true)
+		require.False(t, b.allow("a"))
+		require.True(t, b.allow("b"))
+	})
+
+	t.Run("test single probe after open timeout", func(t *testing.T) {
+		now = now.Add(time.Minute)
+
+		require.True(t, b.allow("a"))
+		require.False(t, b.allow("a"))
+
+		// failed probe keeps the circuit open
+		b.record("a", true)
+		require.False(t, b.allow("a"))
+
+		now = now.Add(time.Minute)
+
+		require.True(t, b.allow("a"))
+		b.cancel("a")
+		require.True(t, b.allow("a"))
+
+		// successful probe closes the circuit
+		b.record("a", false)
+		require.True(t, b.allow("a"))
+		require.True(t, b.allow("a"))
+	})
+}
@@ -0,0 +1,217 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package resilient
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const jitterDivisor = 2
+
+// ErrCircuitOpen is returned for the requests to an endpoint that is skipped after failing repeatedly
+var ErrCircuitOpen = errors.New("circuit breaker is open") //nolint:gochecknoglobals
+
+// Transport is an http.RoundTripper that retries the idempotent requests failing with transport errors,
+// 429 or 5xx responses with exponential backoff and jitter, honouring the Retry-After header.
+// The endpoints that keep failing are skipped for a while when the circuit breaker is enabled.
+// Retries and circuit breaking are disabled by default.
+type Transport struct {
+	base           http.RoundTripper
+	maxRetries     int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	breaker        *circuitBreaker
+}
+
+// NewTransport returns a transport sending the requests with the base transport
+func NewTransport(base http.RoundTripper, opts ...Option) *Transport {
+	t := &Transport{base: base}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
+}
+
+// RoundTrip sends the request, retrying it if it is idempotent
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := req.URL.Scheme + "://" + req.URL.Host
+	retryable := t.maxRetries > 0 && isIdempotent(req)
+
+	for attempt := 0; ; attempt++ {
+		resp, err := t.send(req, endpoint)
+		if !retryable || attempt >= t.maxRetries || !shouldRetry(req, resp, err) {
+			return resp, err
+		}
+
+		if err = t.wait(req, attempt, resp); err != nil {
+			return nil, err
+		}
+
+		if req, err = rewind(req); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// send sends the request unless the circuit of the endpoint is open, and records the outcome
+func (t *Transport) send(req *http.Request, endpoint string) (*http.Response, error) {
+	if t.breaker == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	if !t.breaker.allow(endpoint) {
+		return nil, fmt.Errorf("%w for %s", ErrCircuitOpen, endpoint)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+
+	if req.Context().Err() != nil {
+		t.breaker.cancel(endpoint)
+	} else {
+		t.breaker.record(endpoint, isFailure(resp, err))
+	}
+
+	return resp, err
+}
+
+// wait discards the failed response and waits for the backoff of the attempt
+func (t *Transport) wait(req *http.Request, attempt int, resp *http.Response) error {
+	delay := t.backoff(attempt, resp)
+
+	if resp != nil {
+		discard(resp.Body)
+	}
+
+	select {
+	case <-req.Context().Done():
+		return req.Context().Err()
+	case <-time.After(delay):
+		return nil
+	}
+}
+
+// backoff returns the delay before the next attempt: the delay requested by the Retry-After header,
+// or the exponential backoff for the attempt with jitter
+func (t *Transport) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if delay, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			return delay
+		}
+	}
+
+	delay := t.maxBackoff
+	if shifted := t.initialBackoff << uint(attempt); shifted > 0 && shifted < t.maxBackoff {
+		delay = shifted
+	}
+
+	// equal jitter: between half the delay and the full delay
+	half := int64(delay) / jitterDivisor
+
+	return time.Duration(half + rand.Int63n(half+1)) //nolint:gosec
+}
+
+// retryAfter parses the Retry-After header, which is either a number of seconds or an http date
+func retryAfter(header string) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(header)
+	if err != nil {
+		return 0, false
+	}
+
+	if delay := time.Until(date); delay > 0 {
+		return delay, true
+	}
+
+	return 0, true
+}
+
+// isFailure reports whether the request failed with a transport error or a response worth retrying
+func isFailure(resp *http.Response, err error) bool {
+	return err != nil || resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode >= http.StatusInternalServerError
+}
+
+func shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	return req.Context().Err() == nil && !errors.Is(err, ErrCircuitOpen) && isFailure(resp, err)
+}
+
+// isIdempotent reports whether the request can be sent again
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	default:
+		return false
+	}
+}
+
+// rewind returns a copy of the request with a fresh body
+func rewind(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("failed to rewind request body: %w", err)
+	}
+
+	rewound := req.Clone(req.Context())
+	rewound.Body = body
+
+	return rewound, nil
+}
+
+// discard reads the body so that the connection can be reused
+func discard(body io.ReadCloser) {
+	_, _ = io.Copy(ioutil.Discard, body) //nolint:errcheck
+	_ = body.Close()                     //nolint:errcheck
+}
+
+// Option configures the transport
+type Option func(opts *Transport)
+
+// WithRetry option retries the idempotent requests up to max retries times. The delay before a retry starts at
+// the initial backoff and doubles up to the max backoff, with jitter. Retries are disabled if max retries is zero.
+func WithRetry(maxRetries int, initialBackoff, maxBackoff time.Duration) Option {
+	return func(opts *Transport) {
+		opts.maxRetries = maxRetries
+		opts.initialBackoff = initialBackoff
+		opts.maxBackoff = maxBackoff
+
+		if maxBackoff < initialBackoff {
+			opts.maxBackoff = initialBackoff
+		}
+	}
+}
+
+// WithCircuitBreaker option skips the endpoints failing failure threshold times in a row for the open timeout,
+// the requests to them fail with ErrCircuitOpen. The circuit breaker is disabled if failure threshold is zero.
+func WithCircuitBreaker(failureThreshold int, openTimeout time.Duration) Option {
+	return func(opts *Transport) {
+		opts.breaker = nil
+
+		if failureThreshold > 0 {
+			opts.breaker = newCircuitBreaker(failureThreshold, openTimeout)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package resilient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransport_RoundTrip(t *testing.T) {
+	// the server fails the first requests with the given statuses
+	newServer := func(statuses ...int) (*httptest.Server, *int32) {
+		var count int32
+
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+
+			n := atomic.AddInt32(&count, 1)
+			if int(n) <= len(statuses) {
+				if statuses[n-1] == http.StatusTooManyRequests {
+					w.Header().Set("Retry-After", "0")
+				}
+
+				w.WriteHeader(statuses[n-1])
+
+				return
+			}
+
+			_, err = w.Write(body)
+			require.NoError(t, err)
+		}))
+
+		return serv, &count
+	}
+
+	newClient := func(opts ...Option) *http.Client {
+		return &http.Client{Transport: NewTransport(http.DefaultTransport, opts...)}
+	}
+
+	t.Run("test retries disabled by default", func(t *testing.T) {
+		serv, count := newServer(http.StatusServiceUnavailable)
+		defer serv.Close()
+
+		resp, err := newClient().Get(serv.URL)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		require.EqualValues(t, 1, atomic.LoadInt32(count))
+	})
+
+	t.Run("test idempotent request retried", func(t *testing.T) {
+		serv, count := newServer(http.StatusInternalServerError, http.StatusTooManyRequests)
+		defer serv.Close()
+
+		req, err := http.NewRequest(http.MethodPut, serv.URL, bytes.NewReader([]byte("payload")))
+		require.NoError(t, err)
+
+		resp, err := newClient(WithRetry(2, time.Millisecond, 10*time.Millisecond)).Do(req)
+		require.NoError(t, err)
+
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "payload", string(body))
+		require.EqualValues(t, 3, atomic.LoadInt32(count))
+	})
+
+	t.Run("test retries exhausted", func(t *testing.T) {
+		serv, count := newServer(http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway)
+		defer serv.Close()
+
+		resp, err := newClient(WithRetry(1, time.Millisecond, time.Millisecond)).Get(serv.URL)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, http.StatusBadGateway, resp.StatusCode)
+		require.EqualValues(t, 2, atomic.LoadInt32(count))
+	})
+
+	t.Run("test client errors and non idempotent requests not retried", func(t *testing.T) {
+		serv, count := newServer(http.StatusNotFound, http.StatusServiceUnavailable)
+		defer serv.Close()
+
+		client := newClient(WithRetry(3, time.Millisecond, time.Millisecond))
+
+		resp, err := client.Get(serv.URL)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		resp, err = client.Post(serv.URL, "application/json", bytes.NewReader([]byte("{}")))
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		require.EqualValues(t, 2, atomic.LoadInt32(count))
+	})
+
+	t.Run("test retry stops when the context is done", func(t *testing.T) {
+		serv, count := newServer(http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+		defer serv.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, serv.URL, nil)
+		require.NoError(t, err)
+
+		_, err = newClient(WithRetry(1, time.Hour, time.Hour)).Do(req)
+		require.Error(t, err)
+		require.True(t, errors.Is(err, context.DeadlineExceeded))
+		require.EqualValues(t, 1, atomic.LoadInt32(count))
+	})
+
+	t.Run("test circuit breaker", func(t *testing.T) {
+		serv, count := newServer(http.StatusInternalServerError, http.StatusInternalServerError)
+		defer serv.Close()
+
+		client := newClient(WithCircuitBreaker(2, time.Hour))
+
+		for i := 0; i < 2; i++ {
+			resp, err := client.Get(serv.URL)
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+		}
+
+		_, err := client.Get(serv.URL)
+		require.Error(t, err)
+		require.True(t, errors.Is(err, ErrCircuitOpen))
+		require.EqualValues(t, 2, atomic.LoadInt32(count))
+
+		// circuit open errors are not retried
+		client.Transport.(*Transport).maxRetries = 3
+
+		_, err = client.Get(serv.URL)
+		require.True(t, errors.Is(err, ErrCircuitOpen))
+		require.EqualValues(t, 2, atomic.LoadInt32(count))
+
+		resp, err := newClient(WithCircuitBreaker(0, time.Hour)).Get(serv.URL)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+	})
+}
+
+func TestTransport_Backoff(t *testing.T) {
+	transport := NewTransport(nil, WithRetry(5, 100*time.Millisecond, time.Second))
+
+	t.Run("test exponential backoff with jitter", func(t *testing.T) {
+		for attempt, max := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond,
+			400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second} {
+			delay := transport.backoff(attempt, nil)
+			require.True(t, delay >= max/2 && delay <= max, "attempt %d: %s", attempt, delay)
+		}
+
+		require.True(t, transport.backoff(100, nil) <= time.Second)
+	})
+
+	t.Run("test retry after", func(t *testing.T) {
+		resp := &http.Response{Header: http.Header{}}
+
+		resp.Header.Set("Retry-After", "3")
+		require.Equal(t, 3*time.Second, transport.backoff(0, resp))
+
+		resp.Header.Set("Retry-After", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+		delay := transport.backoff(0, resp)
+		require.True(t, delay > 58*time.Second && delay <= time.Minute)
+
+		resp.Header.Set("Retry-After", time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat))
+		require.Equal(t, time.Duration(0), transport.backoff(0, resp))
+
+		resp.Header.Set("Retry-After", "soon")
+		require.True(t, transport.backoff(0, resp) <= 100*time.Millisecond)
+	})
+
+	t.Run("test max backoff lower than initial backoff", func(t *testing.T) {
+		require.Equal(t, time.Second, NewTransport(nil, WithRetry(1, time.Second, 0)).maxBackoff)
+	})
+}
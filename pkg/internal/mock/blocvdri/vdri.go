@@ -0,0 +1,53 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blocvdri
+
+import (
+	"context"
+
+	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+
+	"github.com/trustbloc/trustbloc-did-method/pkg/vdri/trustbloc"
+)
+
+// VDRI is the mock bloc vdri
+type VDRI struct {
+	ResolveValue *trustbloc.ResolutionResult
+	ResolveErr   error
+
+	DereferenceValue *trustbloc.DereferenceResult
+	DereferenceErr   error
+}
+
+// Resolve resolve did
+func (v *VDRI) Resolve(did string, opts ...vdriapi.ResolveOpts) (*trustbloc.ResolutionResult, error) {
+	if v.ResolveErr != nil {
+		return nil, v.ResolveErr
+	}
+
+	return v.ResolveValue, nil
+}
+
+// Dereference dereference did url
+func (v *VDRI) Dereference(didURL string, opts ...vdriapi.ResolveOpts) (*trustbloc.DereferenceResult, error) {
+	if v.DereferenceErr != nil {
+		return nil, v.DereferenceErr
+	}
+
+	return v.DereferenceValue, nil
+}
+
+// ResolveWithContext resolve did
+func (v *VDRI) ResolveWithContext(_ context.Context, did string,
+	opts ...vdriapi.ResolveOpts) (*trustbloc.ResolutionResult, error) {
+	return v.Resolve(did, opts...)
+}
+
+// DereferenceWithContext dereference did url
+func (v *VDRI) DereferenceWithContext(_ context.Context, didURL string,
+	opts ...vdriapi.ResolveOpts) (*trustbloc.DereferenceResult, error) {
+	return v.Dereference(didURL, opts...)
+}
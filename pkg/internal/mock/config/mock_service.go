@@ -6,13 +6,16 @@ SPDX-License-Identifier: Apache-2.0
 package config
 
 import (
+	"context"
+
 	"github.com/trustbloc/trustbloc-did-method/pkg/vdri/trustbloc/models"
 )
 
 // MockConfigService implements a mock config service
 type MockConfigService struct {
-	GetConsortiumFunc  func(string, string) (*models.ConsortiumFileData, error)
-	GetStakeholderFunc func(string, string) (*models.StakeholderFileData, error)
+	GetConsortiumFunc       func(string, string) (*models.ConsortiumFileData, error)
+	GetStakeholderFunc      func(string, string) (*models.StakeholderFileData, error)
+	GetDIDConfigurationFunc func(string) (*models.DIDConfiguration, error)
 }
 
 // GetConsortium get the consortium config file for a given domain from the given url
@@ -32,3 +35,30 @@ func (m *MockConfigService) GetStakeholder(url, domain string) (*models.Stakehol
 
 	return nil, nil
 }
+
+// GetConsortiumWithContext get the consortium config file for a given domain from the given url
+func (m *MockConfigService) GetConsortiumWithContext(_ context.Context,
+	url, domain string) (*models.ConsortiumFileData, error) {
+	return m.GetConsortium(url, domain)
+}
+
+// GetStakeholderWithContext get the stakeholder config file for a given domain from the given url
+func (m *MockConfigService) GetStakeholderWithContext(_ context.Context,
+	url, domain string) (*models.StakeholderFileData, error) {
+	return m.GetStakeholder(url, domain)
+}
+
+// GetDIDConfiguration get the did configuration from the given url
+func (m *MockConfigService) GetDIDConfiguration(url string) (*models.DIDConfiguration, error) {
+	if m.GetDIDConfigurationFunc != nil {
+		return m.GetDIDConfigurationFunc(url)
+	}
+
+	return nil, nil
+}
+
+// GetDIDConfigurationWithContext get the did configuration from the given url
+func (m *MockConfigService) GetDIDConfigurationWithContext(_ context.Context,
+	url string) (*models.DIDConfiguration, error) {
+	return m.GetDIDConfiguration(url)
+}
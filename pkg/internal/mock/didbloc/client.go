@@ -6,6 +6,8 @@ SPDX-License-Identifier: Apache-2.0
 package didbloc
 
 import (
+	"context"
+
 	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
 
 	didclient "github.com/trustbloc/trustbloc-did-method/pkg/did"
@@ -18,6 +20,17 @@ type Client struct {
 }
 
 // CreateDID create did
-func (c *Client) CreateDID(domain string, opts ...didclient.CreateDIDOption) (*did.Doc, error) {
-	return c.CreateDIDValue, c.CreateDIDErr
+func (c *Client) CreateDID(domain string, opts ...didclient.CreateDIDOption) (*didclient.OperationResult, error) {
+	if c.CreateDIDErr != nil {
+		return nil, c.CreateDIDErr
+	}
+
+	return &didclient.OperationResult{DIDDoc: c.CreateDIDValue,
+		UpdateRevealValue: []byte("update"), RecoveryRevealValue: []byte("recovery")}, nil
+}
+
+// CreateDIDWithContext create did
+func (c *Client) CreateDIDWithContext(_ context.Context, domain string,
+	opts ...didclient.CreateDIDOption) (*didclient.OperationResult, error) {
+	return c.CreateDID(domain, opts...)
 }
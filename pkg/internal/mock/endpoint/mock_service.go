@@ -6,12 +6,15 @@ SPDX-License-Identifier: Apache-2.0
 package endpoint
 
 import (
+	"context"
+
 	"github.com/trustbloc/trustbloc-did-method/pkg/vdri/trustbloc/models"
 )
 
 // MockEndpointService implements a mock endpoint service
 type MockEndpointService struct {
 	GetEndpointsFunc func(domain string) ([]*models.Endpoint, error)
+	GetQuorumFunc    func(domain string) (*models.Quorum, error)
 }
 
 // GetEndpoints discover endpoints for a consortium domain
@@ -22,3 +25,28 @@ func (m *MockEndpointService) GetEndpoints(domain string) ([]*models.Endpoint, e
 
 	return nil, nil
 }
+
+// GetQuorum discover endpoints for a consortium domain and the number of them that must agree.
+// All the endpoints returned by GetEndpointsFunc must agree if GetQuorumFunc isn't set.
+func (m *MockEndpointService) GetQuorum(domain string) (*models.Quorum, error) {
+	if m.GetQuorumFunc != nil {
+		return m.GetQuorumFunc(domain)
+	}
+
+	endpoints, err := m.GetEndpoints(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Quorum{Endpoints: endpoints, Required: len(endpoints)}, nil
+}
+
+// GetEndpointsWithContext discover endpoints for a consortium domain
+func (m *MockEndpointService) GetEndpointsWithContext(_ context.Context, domain string) ([]*models.Endpoint, error) {
+	return m.GetEndpoints(domain)
+}
+
+// GetQuorumWithContext discover endpoints for a consortium domain and the number of them that must agree
+func (m *MockEndpointService) GetQuorumWithContext(_ context.Context, domain string) (*models.Quorum, error) {
+	return m.GetQuorum(domain)
+}
@@ -6,8 +6,14 @@ SPDX-License-Identifier: Apache-2.0
 package models
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/square/go-jose"
 
 	"github.com/trustbloc/trustbloc-did-method/pkg/vdri/trustbloc/models"
 )
@@ -18,6 +24,38 @@ func DummyJWSWrap(data string) string {
 	return `{"payload":"` + dataB64 + `","signatures":[{"header":{"kid":""}, "signature":""}]}`
 }
 
+// GenerateKey generates an Ed25519 signing key with the given key id, and returns its private JWK
+// and the public key to list in a consortium config
+func GenerateKey(kid string) (*jose.JSONWebKey, *models.PublicKey, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &jose.JSONWebKey{Key: priv, KeyID: kid, Algorithm: string(jose.EdDSA)},
+		&models.PublicKey{ID: kid, JWK: &jose.JSONWebKey{Key: pub, KeyID: kid, Algorithm: string(jose.EdDSA)}}, nil
+}
+
+// SignedJWSWrap wraps a config JSON in a JWS signed with each of the private JWKs
+func SignedJWSWrap(data string, keys ...*jose.JSONWebKey) (string, error) {
+	signingKeys := make([]jose.SigningKey, len(keys))
+	for i, key := range keys {
+		signingKeys[i] = jose.SigningKey{Algorithm: jose.SignatureAlgorithm(key.Algorithm), Key: key}
+	}
+
+	signer, err := jose.NewMultiSigner(signingKeys, nil)
+	if err != nil {
+		return "", err
+	}
+
+	jws, err := signer.Sign([]byte(data))
+	if err != nil {
+		return "", err
+	}
+
+	return jws.FullSerialize(), nil
+}
+
 // DummyConsortium creates a default consortium object
 func DummyConsortium(consortiumDomain string, stakeholders []models.StakeholderListElement) *models.Consortium {
 	cc := &models.Consortium{
@@ -50,6 +88,16 @@ func WrapConsortium(consortium *models.Consortium) (string, error) {
 	return DummyJWSWrap(string(out)), nil
 }
 
+// SignConsortium marshals a consortium to JSON and wraps it in a JWS signed with each of the private JWKs
+func SignConsortium(consortium *models.Consortium, keys ...*jose.JSONWebKey) (string, error) {
+	out, err := json.Marshal(consortium)
+	if err != nil {
+		return "", err
+	}
+
+	return SignedJWSWrap(string(out), keys...)
+}
+
 // WrapStakeholder marshals a stakeholder to JSON and wraps it in a dummy JWS
 func WrapStakeholder(stakeholder *models.Stakeholder) (string, error) {
 	out, err := json.Marshal(stakeholder)
@@ -60,6 +108,16 @@ func WrapStakeholder(stakeholder *models.Stakeholder) (string, error) {
 	return DummyJWSWrap(string(out)), nil
 }
 
+// SignStakeholder marshals a stakeholder to JSON and wraps it in a JWS signed with the private JWK
+func SignStakeholder(stakeholder *models.Stakeholder, key *jose.JSONWebKey) (string, error) {
+	out, err := json.Marshal(stakeholder)
+	if err != nil {
+		return "", err
+	}
+
+	return SignedJWSWrap(string(out), key)
+}
+
 // DummyStakeholder creates a dummy stakeholder JSON config
 func DummyStakeholder(stakeholderDomain string, endpoints []string) *models.Stakeholder {
 	return &models.Stakeholder{
@@ -82,3 +140,49 @@ func DummyStakeholderJSON(stakeholderDomain string, endpoints []string) (string,
 
 	return DummyJWSWrap(string(out)), nil
 }
+
+// DIDConfiguration creates a did configuration linking the did to the domain,
+// with a JWT signed with the private JWK
+func DIDConfiguration(did, domain string, key *jose.JSONWebKey) (*models.DIDConfiguration, error) {
+	claims, err := json.Marshal(&models.DomainLinkageClaims{Issuer: did, Domain: domain})
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.SignatureAlgorithm(key.Algorithm), Key: key},
+		(&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		return nil, err
+	}
+
+	jws, err := signer.Sign(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	jwt, err := jws.CompactSerialize()
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.DIDConfiguration{Entries: []models.DomainLinkageAssertion{{DID: did, JWT: jwt}}}, nil
+}
+
+// StakeholderHandler returns an http handler serving the well-known DID configuration of a stakeholder,
+// and the stakeholder config file for any other path
+func StakeholderHandler(stakeholderFile string, didConfig *models.DIDConfiguration) (http.HandlerFunc, error) {
+	didConfigBytes, err := json.Marshal(didConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/.well-known/did-configuration" {
+			fmt.Fprint(w, string(didConfigBytes))
+
+			return
+		}
+
+		fmt.Fprint(w, stakeholderFile)
+	}, nil
+}
@@ -6,12 +6,15 @@ SPDX-License-Identifier: Apache-2.0
 package discovery
 
 import (
+	"context"
+
 	"github.com/trustbloc/trustbloc-did-method/pkg/vdri/trustbloc/models"
 )
 
 // MockSelectionService implements a mock selection service
 type MockSelectionService struct {
 	SelectEndpointsFunc func(domain string, endpoints []*models.Endpoint) ([]*models.Endpoint, error)
+	SelectQuorumFunc    func(domain string, endpoints []*models.Endpoint) (*models.Quorum, error)
 }
 
 // SelectEndpoints select endpoints
@@ -22,3 +25,24 @@ func (m *MockSelectionService) SelectEndpoints(domain string, endpoints []*model
 
 	return nil, nil
 }
+
+// SelectQuorum select endpoints and the number of them that must agree
+func (m *MockSelectionService) SelectQuorum(domain string, endpoints []*models.Endpoint) (*models.Quorum, error) {
+	if m.SelectQuorumFunc != nil {
+		return m.SelectQuorumFunc(domain, endpoints)
+	}
+
+	return &models.Quorum{}, nil
+}
+
+// SelectEndpointsWithContext select endpoints
+func (m *MockSelectionService) SelectEndpointsWithContext(_ context.Context, domain string,
+	endpoints []*models.Endpoint) ([]*models.Endpoint, error) {
+	return m.SelectEndpoints(domain, endpoints)
+}
+
+// SelectQuorumWithContext select endpoints and the number of them that must agree
+func (m *MockSelectionService) SelectQuorumWithContext(_ context.Context, domain string,
+	endpoints []*models.Endpoint) (*models.Quorum, error) {
+	return m.SelectQuorum(domain, endpoints)
+}
@@ -30,5 +30,5 @@ func TestController_GetOperations(t *testing.T) {
 	require.NotNil(t, controller)
 
 	ops := controller.GetOperations()
-	require.Equal(t, 2, len(ops))
+	require.Equal(t, 3, len(ops))
 }
@@ -41,9 +41,13 @@ type DIDState struct {
 	Secret     Secret `json:"secret,omitempty"`
 }
 
-// Secret include keys
+// Secret include keys and the reveal values for the next update and recovery
 type Secret struct {
 	Keys []Key `json:"keys,omitempty"`
+	// UpdateRevealValue is always Base64
+	UpdateRevealValue string `json:"updateRevealValue,omitempty"`
+	// RecoveryRevealValue is always Base64
+	RecoveryRevealValue string `json:"recoveryRevealValue,omitempty"`
 }
 
 // Key include public key and private key
@@ -59,11 +63,14 @@ type PublicKey struct {
 	ID   string `json:"id,omitempty"`
 	Type string `json:"type,omitempty"`
 	// Value is always Base64
-	Value    string   `json:"value,omitempty"`
-	Usage    []string `json:"usage,omitempty"`
-	Encoding string   `json:"encoding,omitempty"`
-	Recovery bool     `json:"recovery,omitempty"`
-	KeyType  string   `json:"keyType,omitempty"`
+	Value string   `json:"value,omitempty"`
+	Usage []string `json:"usage,omitempty"`
+	// Encoding is Jwk, or Base58 for Ed25519VerificationKey2018 and X25519KeyAgreementKey2019 keys,
+	// the Value is Base64 in all cases
+	Encoding string `json:"encoding,omitempty"`
+	Recovery bool   `json:"recovery,omitempty"`
+	// KeyType is one of Ed25519, P256, secp256k1 or X25519
+	KeyType string `json:"keyType,omitempty"`
 }
 
 // Service DID doc service
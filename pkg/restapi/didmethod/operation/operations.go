@@ -6,9 +6,11 @@ SPDX-License-Identifier: Apache-2.0
 package operation
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -28,6 +30,7 @@ const (
 	registerBasePath     = "/1.0"
 	registerPath         = registerBasePath + "/register"
 	resolveDIDEndpoint   = "/resolveDID"
+	dereferenceEndpoint  = "/dereference"
 	didLDJson            = "application/did+ld+json"
 	invalidRequestErrMsg = "invalid request"
 
@@ -46,7 +49,7 @@ type Handler interface {
 
 // Operation defines handlers
 type Operation struct {
-	blocVDRI      vdri.VDRI
+	blocVDRI      blocVDRI
 	didBlocClient didBlocClient
 	blocDomain    string
 }
@@ -60,8 +63,16 @@ type Config struct {
 	SidetreeWriteToken string
 }
 
+type blocVDRI interface {
+	ResolveWithContext(ctx context.Context, did string,
+		opts ...vdri.ResolveOpts) (*trustbloc.ResolutionResult, error)
+	DereferenceWithContext(ctx context.Context, didURL string,
+		opts ...vdri.ResolveOpts) (*trustbloc.DereferenceResult, error)
+}
+
 type didBlocClient interface {
-	CreateDID(domain string, opts ...didclient.CreateDIDOption) (*did.Doc, error)
+	CreateDIDWithContext(ctx context.Context, domain string,
+		opts ...didclient.CreateDIDOption) (*didclient.OperationResult, error)
 }
 
 // New returns did method operation instance
@@ -127,7 +138,7 @@ func (o *Operation) registerDIDHandler(rw http.ResponseWriter, req *http.Request
 			ServiceEndpoint: service.ServiceEndpoint}))
 	}
 
-	didDoc, err := o.didBlocClient.CreateDID(o.blocDomain, opts...)
+	result, err := o.didBlocClient.CreateDIDWithContext(req.Context(), o.blocDomain, opts...)
 	if err != nil {
 		log.Errorf("failed to create did doc : %s", err.Error())
 
@@ -139,8 +150,21 @@ func (o *Operation) registerDIDHandler(rw http.ResponseWriter, req *http.Request
 		return
 	}
 
-	registerResponse.DIDState = DIDState{Identifier: didDoc.ID, State: RegistrationStateFinished,
-		Secret: Secret{Keys: createKeys(keysID, didDoc.ID)}}
+	if result.DIDDoc == nil {
+		log.Errorf("failed to create did doc : the did document is missing from the create result")
+
+		registerResponse.DIDState = DIDState{Reason: "failed to create did doc : the did document is missing " +
+			"from the create result", State: RegistrationStateFailure}
+
+		o.writeResponse(rw, registerResponse)
+
+		return
+	}
+
+	registerResponse.DIDState = DIDState{Identifier: result.DIDDoc.ID, State: RegistrationStateFinished,
+		Secret: Secret{Keys: createKeys(keysID, result.DIDDoc.ID),
+			UpdateRevealValue:   base64.StdEncoding.EncodeToString(result.UpdateRevealValue),
+			RecoveryRevealValue: base64.StdEncoding.EncodeToString(result.RecoveryRevealValue)}}
 
 	o.writeResponse(rw, registerResponse)
 }
@@ -165,7 +189,13 @@ func (o *Operation) resolveDIDHandler(rw http.ResponseWriter, req *http.Request)
 		return
 	}
 
-	didDoc, err := o.blocVDRI.Read(didParam[0])
+	if _, err := didclient.ParseDID(didParam[0]); err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	result, err := o.blocVDRI.ResolveWithContext(req.Context(), didParam[0])
 	if err != nil {
 		o.writeErrorResponse(rw, http.StatusBadRequest,
 			fmt.Sprintf("failed to resolve did: %s", err.Error()))
@@ -173,7 +203,15 @@ func (o *Operation) resolveDIDHandler(rw http.ResponseWriter, req *http.Request)
 		return
 	}
 
-	bytes, err := models.MakeDIDResolutionResult(didDoc)
+	if result.MethodMetadata.Deactivated {
+		o.writeErrorResponse(rw, http.StatusGone,
+			fmt.Sprintf("%s: %s", trustbloc.ErrDIDDeactivated.Error(), didParam[0]))
+
+		return
+	}
+
+	bytes, err := models.MakeDIDResolutionResult(result.DIDDocument, &result.ResolverMetadata,
+		&result.MethodMetadata)
 	if err != nil {
 		o.writeErrorResponse(rw, http.StatusInternalServerError,
 			fmt.Sprintf("failed to marshal did doc: %s", err.Error()))
@@ -189,6 +227,57 @@ func (o *Operation) resolveDIDHandler(rw http.ResponseWriter, req *http.Request)
 	}
 }
 
+func (o *Operation) dereferenceHandler(rw http.ResponseWriter, req *http.Request) {
+	didURLParam, ok := req.URL.Query()["didUrl"]
+
+	if !ok || didURLParam[0] == "" {
+		o.writeErrorResponse(rw, http.StatusBadRequest,
+			fmt.Sprintf("url param 'didUrl' is missing"))
+
+		return
+	}
+
+	result, err := o.blocVDRI.DereferenceWithContext(req.Context(), didURLParam[0])
+	if err != nil {
+		o.writeErrorResponse(rw, dereferenceErrorStatus(err),
+			fmt.Sprintf("failed to dereference did url: %s", err.Error()))
+
+		return
+	}
+
+	if result.Redirect != "" {
+		http.Redirect(rw, req, result.Redirect, http.StatusSeeOther)
+
+		return
+	}
+
+	bytes, err := result.JSONBytes()
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError,
+			fmt.Sprintf("failed to marshal dereferenced resource: %s", err.Error()))
+
+		return
+	}
+
+	rw.Header().Set("Content-type", didLDJson)
+	rw.WriteHeader(http.StatusOK)
+
+	if _, err := rw.Write(bytes); err != nil {
+		log.Errorf("Unable to send error message, %s", err)
+	}
+}
+
+func dereferenceErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, trustbloc.ErrFragmentNotFound), errors.Is(err, trustbloc.ErrServiceNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, trustbloc.ErrDIDDeactivated):
+		return http.StatusGone
+	default:
+		return http.StatusBadRequest
+	}
+}
+
 // writeErrorResponse writes interface value to response
 func (o *Operation) writeErrorResponse(rw http.ResponseWriter, status int, msg string) {
 	rw.WriteHeader(status)
@@ -213,7 +302,8 @@ func (o *Operation) registrarHandlers() []Handler {
 
 func (o *Operation) resolverHandlers() []Handler {
 	return []Handler{
-		support.NewHTTPHandler(resolveDIDEndpoint, http.MethodGet, o.resolveDIDHandler)}
+		support.NewHTTPHandler(resolveDIDEndpoint, http.MethodGet, o.resolveDIDHandler),
+		support.NewHTTPHandler(dereferenceEndpoint, http.MethodGet, o.dereferenceHandler)}
 }
 
 // GetRESTHandlers get all controller API handler available for this service
@@ -16,11 +16,12 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
-	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
-	mockvdri "github.com/hyperledger/aries-framework-go/pkg/mock/vdri"
 	"github.com/stretchr/testify/require"
 
+	"github.com/trustbloc/trustbloc-did-method/pkg/internal/mock/blocvdri"
 	"github.com/trustbloc/trustbloc-did-method/pkg/internal/mock/didbloc"
+	"github.com/trustbloc/trustbloc-did-method/pkg/vdri/trustbloc"
+	"github.com/trustbloc/trustbloc-did-method/pkg/vdri/trustbloc/models"
 )
 
 func TestNew(t *testing.T) {
@@ -30,9 +31,10 @@ func TestNew(t *testing.T) {
 		handlers, err := svc.GetRESTHandlers(combinedMode)
 		require.NoError(t, err)
 		require.NotEmpty(t, handlers)
-		require.Equal(t, 2, len(handlers))
+		require.Equal(t, 3, len(handlers))
 		require.Equal(t, registerPath, handlers[0].Path())
 		require.Equal(t, resolveDIDEndpoint, handlers[1].Path())
+		require.Equal(t, dereferenceEndpoint, handlers[2].Path())
 	})
 
 	t.Run("test registrar mode", func(t *testing.T) {
@@ -51,8 +53,9 @@ func TestNew(t *testing.T) {
 		handlers, err := svc.GetRESTHandlers(resolverMode)
 		require.NoError(t, err)
 		require.NotEmpty(t, handlers)
-		require.Equal(t, 1, len(handlers))
+		require.Equal(t, 2, len(handlers))
 		require.Equal(t, resolveDIDEndpoint, handlers[0].Path())
+		require.Equal(t, dereferenceEndpoint, handlers[1].Path())
 	})
 
 	t.Run("test invalid mode", func(t *testing.T) {
@@ -133,6 +136,27 @@ func TestRegisterDIDHandler(t *testing.T) {
 		require.Contains(t, registerResponse.DIDState.Reason, "error create did")
 	})
 
+	t.Run("test did document missing from create result", func(t *testing.T) {
+		handler := getHandler(t, nil, &didbloc.Client{}, registerPath)
+
+		req, err := json.Marshal(RegisterDIDRequest{JobID: "1", DIDDocument: DIDDocument{
+			PublicKey: []*PublicKey{{ID: "key2",
+				Type: "type", Value: base64.StdEncoding.EncodeToString([]byte("value"))}}}})
+		require.NoError(t, err)
+
+		body, status, err := handleRequest(handler, registerPath, req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, status)
+
+		var registerResponse RegisterResponse
+		require.NoError(t, json.Unmarshal(body.Bytes(), &registerResponse))
+
+		require.Equal(t, "1", registerResponse.JobID)
+		require.Equal(t, RegistrationStateFailure, registerResponse.DIDState.State)
+		require.Contains(t, registerResponse.DIDState.Reason, "the did document is missing from the create result")
+		require.Empty(t, registerResponse.DIDState.Identifier)
+	})
+
 	t.Run("test success with provided public key", func(t *testing.T) {
 		handler := getHandler(t, nil,
 			&didbloc.Client{CreateDIDValue: &did.Doc{ID: "did1"}}, registerPath)
@@ -156,6 +180,10 @@ func TestRegisterDIDHandler(t *testing.T) {
 		require.Equal(t, "did1", registerResponse.DIDState.Identifier)
 		require.Equal(t, 1, len(registerResponse.DIDState.Secret.Keys))
 		require.Equal(t, "did1#key2", registerResponse.DIDState.Secret.Keys[0].ID)
+		require.Equal(t, base64.StdEncoding.EncodeToString([]byte("update")),
+			registerResponse.DIDState.Secret.UpdateRevealValue)
+		require.Equal(t, base64.StdEncoding.EncodeToString([]byte("recovery")),
+			registerResponse.DIDState.Secret.RecoveryRevealValue)
 	})
 }
 
@@ -169,28 +197,119 @@ func TestResolveDIDHandler(t *testing.T) {
 		require.Contains(t, body.String(), "url param 'did' is missing")
 	})
 
-	t.Run("test error from bloc vdri read", func(t *testing.T) {
-		handler := getHandler(t, &mockvdri.MockVDRI{
-			ReadFunc: func(didID string, opts ...vdri.ResolveOpts) (doc *did.Doc, err error) {
-				return nil, fmt.Errorf("read error")
-			}}, nil, resolveDIDEndpoint)
+	t.Run("test invalid did", func(t *testing.T) {
+		handler := getHandler(t, &blocvdri.VDRI{ResolveErr: fmt.Errorf("read error")}, nil, resolveDIDEndpoint)
+
+		body, status, err := handleRequest(handler, resolveDIDEndpoint+"?did=did:trustbloc:test$net:123", nil)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadRequest, status)
+		require.Contains(t, body.String(), "invalid did did:trustbloc:test$net:123: invalid consortium domain")
+	})
+
+	t.Run("test error from bloc vdri resolve", func(t *testing.T) {
+		handler := getHandler(t, &blocvdri.VDRI{ResolveErr: fmt.Errorf("read error")}, nil, resolveDIDEndpoint)
 
-		body, status, err := handleRequest(handler, resolveDIDEndpoint+"?did=123", nil)
+		body, status, err := handleRequest(handler, resolveDIDEndpoint+"?did=did:trustbloc:testnet:123", nil)
 		require.NoError(t, err)
 		require.Equal(t, http.StatusBadRequest, status)
 		require.Contains(t, body.String(), "read error")
 	})
 
+	t.Run("test deactivated did", func(t *testing.T) {
+		handler := getHandler(t, &blocvdri.VDRI{ResolveValue: &trustbloc.ResolutionResult{
+			MethodMetadata: models.MethodMetaData{Deactivated: true}}}, nil, resolveDIDEndpoint)
+
+		body, status, err := handleRequest(handler, resolveDIDEndpoint+"?did=did:trustbloc:testnet:123", nil)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusGone, status)
+		require.Contains(t, body.String(), "did has been deactivated")
+	})
+
 	t.Run("test success", func(t *testing.T) {
-		handler := getHandler(t, &mockvdri.MockVDRI{
-			ReadFunc: func(didID string, opts ...vdri.ResolveOpts) (doc *did.Doc, err error) {
-				return &did.Doc{ID: "didID", Context: []string{"context"}}, nil
-			}}, nil, resolveDIDEndpoint)
+		handler := getHandler(t, &blocvdri.VDRI{ResolveValue: &trustbloc.ResolutionResult{
+			DIDDocument: &did.Doc{ID: "didID", Context: []string{"context"}},
+			MethodMetadata: models.MethodMetaData{Published: true, RequiredAgreement: 1,
+				Endpoints: []models.EndpointMetadata{{URL: "url", Responded: true, Agreed: true}}}}},
+			nil, resolveDIDEndpoint)
 
-		body, status, err := handleRequest(handler, resolveDIDEndpoint+"?did=123", nil)
+		body, status, err := handleRequest(handler, resolveDIDEndpoint+"?did=did:trustbloc:testnet:123", nil)
 		require.NoError(t, err)
 		require.Equal(t, http.StatusOK, status)
-		require.Contains(t, body.String(), "didID")
+
+		var result models.DIDResolutionResult
+		require.NoError(t, json.Unmarshal(body.Bytes(), &result))
+		require.Contains(t, string(result.DIDDocument), "didID")
+		require.True(t, result.MethodMetadata.Published)
+		require.Equal(t, 1, result.MethodMetadata.RequiredAgreement)
+		require.True(t, result.MethodMetadata.Endpoints[0].Agreed)
+	})
+
+}
+
+func TestDereferenceHandler(t *testing.T) {
+	doc := &did.Doc{ID: "did:trustbloc:dom:abc", Context: []string{"context"},
+		PublicKey: []did.PublicKey{{ID: "did:trustbloc:dom:abc#key-1", Type: "type",
+			Controller: "did:trustbloc:dom:abc", Value: []byte("value")}},
+		Service: []did.Service{{ID: "did:trustbloc:dom:abc#hub", Type: "hub",
+			ServiceEndpoint: "https://hub.example.com"}}}
+
+	t.Run("test did url param missing", func(t *testing.T) {
+		handler := getHandler(t, nil, nil, dereferenceEndpoint)
+
+		body, status, err := handleRequest(handler, dereferenceEndpoint, nil)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadRequest, status)
+		require.Contains(t, body.String(), "url param 'didUrl' is missing")
+	})
+
+	t.Run("test error from bloc vdri dereference", func(t *testing.T) {
+		for _, tc := range []struct {
+			err    error
+			status int
+		}{
+			{err: fmt.Errorf("read error"), status: http.StatusBadRequest},
+			{err: fmt.Errorf("%w: key-2", trustbloc.ErrFragmentNotFound), status: http.StatusNotFound},
+			{err: fmt.Errorf("%w: hub", trustbloc.ErrServiceNotFound), status: http.StatusNotFound},
+			{err: fmt.Errorf("%w: did", trustbloc.ErrDIDDeactivated), status: http.StatusGone},
+		} {
+			handler := getHandler(t, &blocvdri.VDRI{DereferenceErr: tc.err}, nil, dereferenceEndpoint)
+
+			body, status, err := handleRequest(handler, dereferenceEndpoint+"?didUrl=123", nil)
+			require.NoError(t, err)
+			require.Equal(t, tc.status, status)
+			require.Contains(t, body.String(), tc.err.Error())
+		}
+	})
+
+	t.Run("test success", func(t *testing.T) {
+		handler := getHandler(t, &blocvdri.VDRI{DereferenceValue: &trustbloc.DereferenceResult{
+			DIDDocument: doc, PublicKey: &doc.PublicKey[0]}}, nil, dereferenceEndpoint)
+
+		body, status, err := handleRequest(handler, dereferenceEndpoint+"?didUrl=123", nil)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, status)
+
+		var key map[string]interface{}
+		require.NoError(t, json.Unmarshal(body.Bytes(), &key))
+		require.Equal(t, "did:trustbloc:dom:abc#key-1", key["id"])
+	})
+
+	t.Run("test redirect", func(t *testing.T) {
+		handler := getHandler(t, &blocvdri.VDRI{DereferenceValue: &trustbloc.DereferenceResult{
+			DIDDocument: doc, Service: &doc.Service[0], Redirect: "https://hub.example.com/path"}},
+			nil, dereferenceEndpoint)
+
+		req, err := http.NewRequest(http.MethodGet, dereferenceEndpoint+"?didUrl=123", nil)
+		require.NoError(t, err)
+
+		router := mux.NewRouter()
+		router.HandleFunc(handler.Path(), handler.Handle()).Methods(handler.Method())
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusSeeOther, rr.Code)
+		require.Equal(t, "https://hub.example.com/path", rr.Header().Get("Location"))
 	})
 }
 
@@ -212,7 +331,7 @@ func handleRequest(handler Handler, path string, body []byte) (*bytes.Buffer, in
 	return rr.Body, rr.Code, nil
 }
 
-func getHandler(t *testing.T, blocVDRI vdri.VDRI,
+func getHandler(t *testing.T, blocVDRI blocVDRI,
 	didBlocClient didBlocClient, lookup string) Handler {
 	svc := New(&Config{})
 	require.NotNil(t, svc)
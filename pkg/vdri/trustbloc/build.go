@@ -0,0 +1,152 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package trustbloc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcutil/base58"
+	docdid "github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+
+	didclient "github.com/trustbloc/trustbloc-did-method/pkg/did"
+)
+
+const (
+	buildKeyID     = "key-1"
+	buildServiceID = "endpoint-1"
+)
+
+// RevealValues are the reveal values of a did created by Build, they are kept in the reveal value store
+// as JSON under the did
+type RevealValues struct {
+	// UpdateRevealValue is the reveal value for the first update operation
+	UpdateRevealValue []byte `json:"updateRevealValue"`
+	// RecoveryRevealValue is the reveal value for the first recover or deactivate operation
+	RecoveryRevealValue []byte `json:"recoveryRevealValue"`
+}
+
+// Build creates a trustbloc did in the configured consortium domain, with the public key and
+// the service (type, endpoint and routing keys) from the options. The public key is also the recovery key,
+// so X25519 keys are rejected. The update and recovery reveal values of the did are put in the reveal value store,
+// which is required so that the did can be updated and recovered later on.
+func (v *VDRI) Build(pubKey *vdriapi.PubKey, opts ...vdriapi.DocOpts) (*docdid.Doc, error) {
+	if v.domain == "" {
+		return nil, errors.New("consortium domain is not configured")
+	}
+
+	if v.revealValueStore == nil {
+		return nil, errors.New("reveal value store is not configured")
+	}
+
+	docOpts := &vdriapi.CreateDIDOpts{}
+
+	for _, opt := range opts {
+		opt(docOpts)
+	}
+
+	publicKeys, services, err := buildDocument(pubKey, docOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	var createOpts []didclient.CreateDIDOption
+
+	for _, publicKey := range publicKeys {
+		createOpts = append(createOpts, didclient.WithPublicKey(publicKey))
+	}
+
+	for _, service := range services {
+		createOpts = append(createOpts, didclient.WithService(service))
+	}
+
+	result, err := v.didClient.CreateDID(v.domain, createOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create did: %w", err)
+	}
+
+	if result.DIDDoc == nil {
+		return nil, errors.New("failed to create did: the sidetree node didn't return the did document")
+	}
+
+	err = v.storeRevealValues(result)
+	if err != nil {
+		return nil, fmt.Errorf("did %s was created but %w", result.DIDDoc.ID, err)
+	}
+
+	return result.DIDDoc, nil
+}
+
+func (v *VDRI) storeRevealValues(result *didclient.OperationResult) error {
+	revealValues, err := json.Marshal(&RevealValues{UpdateRevealValue: result.UpdateRevealValue,
+		RecoveryRevealValue: result.RecoveryRevealValue})
+	if err != nil {
+		return fmt.Errorf("failed to marshal its reveal values: %w", err)
+	}
+
+	err = v.revealValueStore.Put(result.DIDDoc.ID, revealValues)
+	if err != nil {
+		return fmt.Errorf("failed to store its reveal values: %w", err)
+	}
+
+	return nil
+}
+
+// buildDocument returns the document public key, the recovery key and the services for the build options
+func buildDocument(pubKey *vdriapi.PubKey,
+	docOpts *vdriapi.CreateDIDOpts) ([]*didclient.PublicKey, []*docdid.Service, error) {
+	if pubKey == nil || pubKey.Value == "" {
+		return nil, nil, errors.New("public key is required")
+	}
+
+	// Ed25519VerificationKey2018 is the default key type of the vdri registry
+	publicKeyType := pubKey.Type
+	if publicKeyType == "" {
+		publicKeyType = didclient.Ed25519VerificationKey2018
+	}
+
+	keyType, usage, err := keyTypeOf(publicKeyType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	value := base58.Decode(pubKey.Value)
+
+	publicKeys := []*didclient.PublicKey{
+		{ID: buildKeyID, Type: publicKeyType, Encoding: didclient.PublicKeyEncodingJwk, KeyType: keyType,
+			Usage: []string{usage}, Value: value},
+		{ID: buildKeyID, Type: publicKeyType, Encoding: didclient.PublicKeyEncodingJwk, KeyType: keyType,
+			Recovery: true, Value: value},
+	}
+
+	if docOpts.ServiceType == "" {
+		return publicKeys, nil, nil
+	}
+
+	service := &docdid.Service{ID: buildServiceID, Type: docOpts.ServiceType,
+		ServiceEndpoint: docOpts.ServiceEndpoint, RoutingKeys: docOpts.RoutingKeys}
+
+	if docOpts.ServiceType == vdriapi.DIDCommServiceType {
+		service.RecipientKeys = []string{pubKey.Value}
+	}
+
+	return publicKeys, []*docdid.Service{service}, nil
+}
+
+// keyTypeOf returns the did client key type and usage of a public key type.
+// X25519 key agreement keys can't be recovery keys, so they are not supported.
+func keyTypeOf(publicKeyType string) (string, string, error) {
+	switch publicKeyType {
+	case didclient.Ed25519VerificationKey2018:
+		return didclient.Ed25519KeyType, didclient.KeyUsageGeneral, nil
+	case didclient.EcdsaSecp256k1VerificationKey2019:
+		return didclient.Secp256k1KeyType, didclient.KeyUsageGeneral, nil
+	default:
+		return "", "", fmt.Errorf("public key type not supported: %s", publicKeyType)
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package trustbloc
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+const defaultCacheMaxEntries = 1000
+
+// CacheStats holds the counters of the resolution cache
+type CacheStats struct {
+	Hits    uint64
+	Misses  uint64
+	Entries int
+}
+
+type cacheEntry struct {
+	did    string
+	result *ResolutionResult
+	err    error
+	expiry time.Time
+}
+
+// resolutionCache is a bounded in-memory cache of resolution results, the least recently used entries
+// are evicted first. Not found errors and documents built from an initial state (not published yet)
+// are kept for the not found ttl.
+type resolutionCache struct {
+	ttl         time.Duration
+	notFoundTTL time.Duration
+	maxEntries  int
+	now         func() time.Time // needed for unit test
+
+	mutex   sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	hits    uint64
+	misses  uint64
+}
+
+func newResolutionCache(ttl, notFoundTTL time.Duration, maxEntries int) *resolutionCache {
+	if maxEntries <= 0 {
+		maxEntries = defaultCacheMaxEntries
+	}
+
+	return &resolutionCache{ttl: ttl, notFoundTTL: notFoundTTL, maxEntries: maxEntries, now: time.Now,
+		entries: make(map[string]*list.Element), lru: list.New()}
+}
+
+// get returns the cached result or not found error of the did, if it hasn't expired
+func (c *resolutionCache) get(did string) (*cacheEntry, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.entries[did]
+	if !ok {
+		c.misses++
+
+		return nil, false
+	}
+
+	entry := element.Value.(*cacheEntry) //nolint:errcheck
+	if !c.now().Before(entry.expiry) {
+		c.remove(element)
+		c.misses++
+
+		return nil, false
+	}
+
+	c.lru.MoveToFront(element)
+	c.hits++
+
+	return entry, true
+}
+
+// add caches the result or not found error of the did
+func (c *resolutionCache) add(did string, result *ResolutionResult, err error) {
+	ttl := c.ttl
+	if err != nil || (!result.MethodMetadata.Published && !result.MethodMetadata.Deactivated) {
+		ttl = c.notFoundTTL
+	}
+
+	if ttl <= 0 {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry := &cacheEntry{did: did, result: result, err: err, expiry: c.now().Add(ttl)}
+
+	if element, ok := c.entries[did]; ok {
+		element.Value = entry
+		c.lru.MoveToFront(element)
+
+		return
+	}
+
+	c.entries[did] = c.lru.PushFront(entry)
+
+	if c.lru.Len() > c.maxEntries {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *resolutionCache) remove(element *list.Element) {
+	c.lru.Remove(element)
+	delete(c.entries, element.Value.(*cacheEntry).did) //nolint:errcheck
+}
+
+func (c *resolutionCache) stats() CacheStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return CacheStats{Hits: c.hits, Misses: c.misses, Entries: c.lru.Len()}
+}
@@ -6,49 +6,69 @@ SPDX-License-Identifier: Apache-2.0
 package httpconfig
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/trustbloc/trustbloc-did-method/pkg/internal/common/resilient"
 	"github.com/trustbloc/trustbloc-did-method/pkg/vdri/trustbloc/models"
 )
 
+const defaultTimeout = 10 * time.Second
+
 // ConfigService fetches consortium and stakeholder configs over http
 type ConfigService struct {
-	httpClient *http.Client
-	tlsConfig  *tls.Config
+	httpClient    *http.Client
+	tlsConfig     *tls.Config
+	timeout       time.Duration
+	transportOpts []resilient.Option
 }
 
 // NewService create new ConfigService
 func NewService(opts ...Option) *ConfigService {
-	configService := &ConfigService{httpClient: &http.Client{}}
+	configService := &ConfigService{httpClient: &http.Client{}, timeout: defaultTimeout}
 
 	for _, opt := range opts {
 		opt(configService)
 	}
 
-	configService.httpClient.Transport = &http.Transport{TLSClientConfig: configService.tlsConfig}
+	configService.httpClient.Transport = resilient.NewTransport(
+		&http.Transport{TLSClientConfig: configService.tlsConfig}, configService.transportOpts...)
+	configService.httpClient.Timeout = configService.timeout
 
 	return configService
 }
 
 const consortiumURLInfix = "/.well-known/did-trustbloc/"
 const consortiumURLSuffix = ".json"
+const didConfigurationPath = "/.well-known/did-configuration"
 
 func configURL(urlDomain, consortiumDomain string) string {
-	prefix := ""
+	return baseURL(urlDomain) + consortiumURLInfix + consortiumDomain + consortiumURLSuffix
+}
+
+func baseURL(urlDomain string) string {
 	if !strings.HasPrefix(urlDomain, "http://") && !strings.HasPrefix(urlDomain, "https://") {
-		prefix = "https://"
+		return "https://" + urlDomain
 	}
 
-	return prefix + urlDomain + consortiumURLInfix + consortiumDomain + consortiumURLSuffix
+	return urlDomain
 }
 
 // GetConsortium fetches and parses the consortium file at the given domain
 func (cs *ConfigService) GetConsortium(url, domain string) (*models.ConsortiumFileData, error) {
-	res, err := cs.httpClient.Get(configURL(url, domain))
+	return cs.GetConsortiumWithContext(context.Background(), url, domain)
+}
+
+// GetConsortiumWithContext fetches and parses the consortium file at the given domain,
+// the request is cancelled with the context
+func (cs *ConfigService) GetConsortiumWithContext(ctx context.Context,
+	url, domain string) (*models.ConsortiumFileData, error) {
+	res, err := cs.get(ctx, configURL(url, domain))
 	if err != nil {
 		return nil, err
 	}
@@ -62,7 +82,6 @@ func (cs *ConfigService) GetConsortium(url, domain string) (*models.ConsortiumFi
 	}
 
 	if res.StatusCode != 200 {
-		// TODO retry
 		return nil, fmt.Errorf("consortium config request failed: error %d, `%s`", res.StatusCode, string(body))
 	}
 
@@ -71,7 +90,14 @@ func (cs *ConfigService) GetConsortium(url, domain string) (*models.ConsortiumFi
 
 // GetStakeholder fetches and parses a stakeholder file under the given url with the given domain
 func (cs *ConfigService) GetStakeholder(url, domain string) (*models.StakeholderFileData, error) {
-	res, err := cs.httpClient.Get(configURL(url, domain))
+	return cs.GetStakeholderWithContext(context.Background(), url, domain)
+}
+
+// GetStakeholderWithContext fetches and parses a stakeholder file under the given url with the given domain,
+// the request is cancelled with the context
+func (cs *ConfigService) GetStakeholderWithContext(ctx context.Context,
+	url, domain string) (*models.StakeholderFileData, error) {
+	res, err := cs.get(ctx, configURL(url, domain))
 	if err != nil {
 		return nil, err
 	}
@@ -85,13 +111,50 @@ func (cs *ConfigService) GetStakeholder(url, domain string) (*models.Stakeholder
 	}
 
 	if res.StatusCode != 200 {
-		// TODO retry
 		return nil, fmt.Errorf("stakeholder config request failed: error %d, `%s`", res.StatusCode, string(body))
 	}
 
 	return models.ParseStakeholder(body)
 }
 
+// GetDIDConfiguration fetches and parses the well-known DID configuration under the given url
+func (cs *ConfigService) GetDIDConfiguration(url string) (*models.DIDConfiguration, error) {
+	return cs.GetDIDConfigurationWithContext(context.Background(), url)
+}
+
+// GetDIDConfigurationWithContext fetches and parses the well-known DID configuration under the given url,
+// the request is cancelled with the context
+func (cs *ConfigService) GetDIDConfigurationWithContext(ctx context.Context,
+	url string) (*models.DIDConfiguration, error) {
+	res, err := cs.get(ctx, baseURL(url)+didConfigurationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// nolint: errcheck
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != 200 {
+		return nil, fmt.Errorf("did configuration request failed: error %d, `%s`", res.StatusCode, string(body))
+	}
+
+	return models.ParseDIDConfiguration(body)
+}
+
+func (cs *ConfigService) get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return cs.httpClient.Do(req)
+}
+
 // Option is a config service instance option
 type Option func(opts *ConfigService)
 
@@ -101,3 +164,25 @@ func WithTLSConfig(tlsConfig *tls.Config) Option {
 		opts.tlsConfig = tlsConfig
 	}
 }
+
+// WithTimeout option sets the timeout of the config requests (10 seconds by default)
+func WithTimeout(timeout time.Duration) Option {
+	return func(opts *ConfigService) {
+		opts.timeout = timeout
+	}
+}
+
+// WithRetry option retries the config requests failing with transport errors, 429 or 5xx responses
+// up to max retries times, with a backoff doubling from the initial backoff up to the max backoff
+func WithRetry(maxRetries int, initialBackoff, maxBackoff time.Duration) Option {
+	return func(opts *ConfigService) {
+		opts.transportOpts = append(opts.transportOpts, resilient.WithRetry(maxRetries, initialBackoff, maxBackoff))
+	}
+}
+
+// WithCircuitBreaker option skips the config servers failing failure threshold times in a row for the open timeout
+func WithCircuitBreaker(failureThreshold int, openTimeout time.Duration) Option {
+	return func(opts *ConfigService) {
+		opts.transportOpts = append(opts.transportOpts, resilient.WithCircuitBreaker(failureThreshold, openTimeout))
+	}
+}
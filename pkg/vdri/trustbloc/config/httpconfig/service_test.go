@@ -6,11 +6,14 @@ SPDX-License-Identifier: Apache-2.0
 package httpconfig
 
 import (
+	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -53,6 +56,24 @@ func TestConfigService_GetConsortium(t *testing.T) {
 		require.Contains(t, err.Error(), "connection refused")
 	})
 
+	t.Run("failure: context cancelled", func(t *testing.T) {
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}))
+		defer serv.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		cs := NewService()
+		_, err := cs.GetConsortiumWithContext(ctx, serv.URL, "foo.bar")
+		require.Error(t, err)
+		require.True(t, errors.Is(err, context.DeadlineExceeded))
+
+		_, err = cs.GetStakeholderWithContext(ctx, serv.URL, "foo.bar")
+		require.True(t, errors.Is(err, context.DeadlineExceeded))
+	})
+
 	t.Run("failure: bad response", func(t *testing.T) {
 		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(500)
@@ -77,6 +98,47 @@ func TestConfigService_GetConsortium(t *testing.T) {
 
 		require.Contains(t, err.Error(), "consortium config data should be a JWS")
 	})
+
+	t.Run("success after retry", func(t *testing.T) {
+		consortiumFile, err := mockmodels.WrapConsortium(mockmodels.DummyConsortium("foo.bar", nil))
+		require.NoError(t, err)
+
+		requests := 0
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if requests == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+
+			fmt.Fprint(w, consortiumFile)
+		}))
+		defer serv.Close()
+
+		cs := NewService(WithRetry(2, time.Millisecond, time.Millisecond))
+
+		conf, err := cs.GetConsortium(serv.URL, "foo.bar")
+		require.NoError(t, err)
+		require.Equal(t, "foo.bar", conf.Config.Domain)
+		require.Equal(t, 2, requests)
+	})
+
+	t.Run("failure: circuit breaker open", func(t *testing.T) {
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer serv.Close()
+
+		cs := NewService(WithCircuitBreaker(1, time.Hour))
+
+		_, err := cs.GetConsortium(serv.URL, "foo.bar")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "consortium config request failed")
+
+		_, err = cs.GetStakeholder(serv.URL, "foo.bar")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "circuit breaker is open")
+	})
 }
 
 func TestConfigService_GetStakeholder(t *testing.T) {
@@ -136,6 +198,55 @@ func TestConfigService_GetStakeholder(t *testing.T) {
 	})
 }
 
+func TestConfigService_GetDIDConfiguration(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "/.well-known/did-configuration", r.URL.Path)
+			fmt.Fprint(w, `{"entries":[{"did":"did:trustbloc:foo.bar:123","jwt":"jwt"}]}`)
+		}))
+		defer serv.Close()
+
+		cs := NewService()
+
+		conf, err := cs.GetDIDConfiguration(serv.URL)
+		require.NoError(t, err)
+		require.Len(t, conf.Entries, 1)
+		require.Equal(t, "did:trustbloc:foo.bar:123", conf.Entries[0].DID)
+	})
+
+	t.Run("failure: can't reach server", func(t *testing.T) {
+		cs := NewService()
+
+		_, err := cs.GetDIDConfiguration("0.0.0.0:8080")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "connection refused")
+	})
+
+	t.Run("failure: bad response", func(t *testing.T) {
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(404)
+		}))
+		defer serv.Close()
+
+		cs := NewService()
+
+		_, err := cs.GetDIDConfiguration(serv.URL)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "did configuration request failed: error 404")
+	})
+
+	t.Run("failure: empty response", func(t *testing.T) {
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer serv.Close()
+
+		cs := NewService()
+
+		_, err := cs.GetDIDConfiguration(serv.URL)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to parse did configuration")
+	})
+}
+
 func Test_configURL(t *testing.T) {
 	tests := [][2]string{ // first element is the test value, second is the correct value
 		{
@@ -177,7 +288,8 @@ func TestOpts(t *testing.T) {
 	t.Run("test opts", func(t *testing.T) {
 		// test WithTLSConfig
 		var opts []Option
-		opts = append(opts, WithTLSConfig(&tls.Config{ServerName: "test"}))
+		opts = append(opts, WithTLSConfig(&tls.Config{ServerName: "test"}), WithTimeout(time.Second),
+			WithRetry(2, time.Millisecond, time.Second), WithCircuitBreaker(5, time.Minute))
 
 		cs := &ConfigService{}
 
@@ -187,5 +299,7 @@ func TestOpts(t *testing.T) {
 		}
 
 		require.Equal(t, "test", cs.tlsConfig.ServerName)
+		require.Equal(t, time.Second, cs.timeout)
+		require.Len(t, cs.transportOpts, 2)
 	})
 }
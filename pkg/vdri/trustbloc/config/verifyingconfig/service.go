@@ -7,6 +7,7 @@ package verifyingconfig
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"math/rand"
 
@@ -16,8 +17,9 @@ import (
 )
 
 type config interface {
-	GetConsortium(string, string) (*models.ConsortiumFileData, error)
-	GetStakeholder(string, string) (*models.StakeholderFileData, error)
+	GetConsortiumWithContext(context.Context, string, string) (*models.ConsortiumFileData, error)
+	GetStakeholderWithContext(context.Context, string, string) (*models.StakeholderFileData, error)
+	GetDIDConfigurationWithContext(context.Context, string) (*models.DIDConfiguration, error)
 }
 
 // ConfigService fetches consortium and stakeholder configs over http
@@ -32,9 +34,17 @@ func NewService(config config) *ConfigService {
 	return configService
 }
 
-// GetConsortium fetches and parses the consortium file at the given domain
+// GetConsortium fetches, parses and verifies the consortium file at the given domain
 func (cs *ConfigService) GetConsortium(url, domain string) (*models.ConsortiumFileData, error) {
-	consortiumData, err := cs.config.GetConsortium(url, domain)
+	return cs.GetConsortiumWithContext(context.Background(), url, domain)
+}
+
+// GetConsortiumWithContext fetches and parses the consortium file at the given domain, verifies the stakeholder
+// signatures endorsing it and compares it with the copies served by the stakeholders,
+// the requests are cancelled with the context
+func (cs *ConfigService) GetConsortiumWithContext(ctx context.Context,
+	url, domain string) (*models.ConsortiumFileData, error) {
+	consortiumData, err := cs.config.GetConsortiumWithContext(ctx, url, domain)
 	if err != nil {
 		return nil, fmt.Errorf("wrapped config service: %w", err)
 	}
@@ -44,6 +54,11 @@ func (cs *ConfigService) GetConsortium(url, domain string) (*models.ConsortiumFi
 		return nil, fmt.Errorf("consortium is nil")
 	}
 
+	err = consortiumData.VerifyEndorsements()
+	if err != nil {
+		return nil, err
+	}
+
 	n := consortium.Policy.NumQueries
 
 	// if ds.numStakeholders is 0, then we use all stakeholders
@@ -59,7 +74,7 @@ func (cs *ConfigService) GetConsortium(url, domain string) (*models.ConsortiumFi
 	for i := 0; i < n; i++ {
 		stakeholder := consortium.Members[perm[i]].Domain
 		// get consortium file from stakeholder server
-		file, err := cs.config.GetConsortium(stakeholder, domain)
+		file, err := cs.config.GetConsortiumWithContext(ctx, stakeholder, domain)
 		if err != nil {
 			log.Warnf("stakeholder peer failed to return consortium config")
 			continue // skip failed stakeholders
@@ -73,7 +88,7 @@ func (cs *ConfigService) GetConsortium(url, domain string) (*models.ConsortiumFi
 	}
 
 	if verifiedCount < n {
-		return nil, fmt.Errorf("insufficient stakeholder endorsement of consortium config file")
+		return nil, models.ErrInsufficientEndorsement
 	}
 
 	return consortiumData, nil
@@ -81,5 +96,24 @@ func (cs *ConfigService) GetConsortium(url, domain string) (*models.ConsortiumFi
 
 // GetStakeholder returns the stakeholder config file fetched by the wrapped config service
 func (cs *ConfigService) GetStakeholder(url, domain string) (*models.StakeholderFileData, error) {
-	return cs.config.GetStakeholder(url, domain)
+	return cs.config.GetStakeholderWithContext(context.Background(), url, domain)
+}
+
+// GetStakeholderWithContext returns the stakeholder config file fetched by the wrapped config service,
+// the request is cancelled with the context
+func (cs *ConfigService) GetStakeholderWithContext(ctx context.Context,
+	url, domain string) (*models.StakeholderFileData, error) {
+	return cs.config.GetStakeholderWithContext(ctx, url, domain)
+}
+
+// GetDIDConfiguration returns the DID configuration fetched by the wrapped config service
+func (cs *ConfigService) GetDIDConfiguration(url string) (*models.DIDConfiguration, error) {
+	return cs.config.GetDIDConfigurationWithContext(context.Background(), url)
+}
+
+// GetDIDConfigurationWithContext returns the DID configuration fetched by the wrapped config service,
+// the request is cancelled with the context
+func (cs *ConfigService) GetDIDConfigurationWithContext(ctx context.Context,
+	url string) (*models.DIDConfiguration, error) {
+	return cs.config.GetDIDConfigurationWithContext(ctx, url)
 }
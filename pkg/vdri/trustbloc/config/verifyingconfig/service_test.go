@@ -6,6 +6,7 @@ SPDX-License-Identifier: Apache-2.0
 package verifyingconfig
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -38,16 +39,23 @@ func TestConfigService_GetConsortium(t *testing.T) {
 		}))
 		defer s2Serv.Close()
 
-		var err error
+		privKey1, pubKey1, err := mockmodels.GenerateKey("key1")
+		require.NoError(t, err)
 
-		consortiumFile, err = mockmodels.DummyConsortiumJSON("foo.bar", []models.StakeholderListElement{
-			{
-				Domain: s1Serv.URL,
-			},
-			{
-				Domain: s2Serv.URL,
-			},
-		})
+		privKey2, pubKey2, err := mockmodels.GenerateKey("key2")
+		require.NoError(t, err)
+
+		consortiumFile, err = mockmodels.SignConsortium(mockmodels.DummyConsortium("foo.bar",
+			[]models.StakeholderListElement{
+				{
+					Domain:    s1Serv.URL,
+					PublicKey: pubKey1,
+				},
+				{
+					Domain:    s2Serv.URL,
+					PublicKey: pubKey2,
+				},
+			}), privKey1, privKey2)
 		require.NoError(t, err)
 
 		cs := NewService(httpconfig.NewService())
@@ -79,22 +87,76 @@ func TestConfigService_GetConsortium(t *testing.T) {
 		}))
 		defer s2Serv.Close()
 
-		consortiumFile, err = mockmodels.DummyConsortiumJSON("foo.bar", []models.StakeholderListElement{
+		privKey1, pubKey1, err := mockmodels.GenerateKey("key1")
+		require.NoError(t, err)
+
+		privKey2, pubKey2, err := mockmodels.GenerateKey("key2")
+		require.NoError(t, err)
+
+		consortiumFile, err = mockmodels.SignConsortium(mockmodels.DummyConsortium("foo.bar",
+			[]models.StakeholderListElement{
+				{
+					Domain:    s1Serv.URL,
+					PublicKey: pubKey1,
+				},
+				{
+					Domain:    s2Serv.URL,
+					PublicKey: pubKey2,
+				},
+			}), privKey1, privKey2)
+		require.NoError(t, err)
+
+		cs := NewService(httpconfig.NewService())
+
+		_, err = cs.GetConsortium(cServ.URL, "foo.bar")
+		require.Error(t, err)
+
+		require.Contains(t, err.Error(), "endorsement")
+	})
+
+	t.Run("failure - consortium not signed by enough stakeholders", func(t *testing.T) {
+		consortiumFile := ""
+
+		cServ := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, consortiumFile)
+		}))
+		defer cServ.Close()
+
+		privKey1, pubKey1, err := mockmodels.GenerateKey("key1")
+		require.NoError(t, err)
+
+		_, pubKey2, err := mockmodels.GenerateKey("key2")
+		require.NoError(t, err)
+
+		consortium := mockmodels.DummyConsortium("foo.bar", []models.StakeholderListElement{
 			{
-				Domain: s1Serv.URL,
+				Domain:    cServ.URL,
+				PublicKey: pubKey1,
 			},
 			{
-				Domain: s2Serv.URL,
-			},
-		})
+				Domain:    cServ.URL,
+				PublicKey: pubKey2,
+			}})
+
+		consortiumFile, err = mockmodels.SignConsortium(consortium, privKey1)
 		require.NoError(t, err)
 
 		cs := NewService(httpconfig.NewService())
 
 		_, err = cs.GetConsortium(cServ.URL, "foo.bar")
 		require.Error(t, err)
+		require.True(t, errors.Is(err, models.ErrInsufficientEndorsement))
+		require.Contains(t, err.Error(), "1 of 2 required stakeholder signatures verified")
 
-		require.Contains(t, err.Error(), "endorsement")
+		// only require one stakeholder to endorse
+		consortium.Policy.NumQueries = 1
+
+		consortiumFile, err = mockmodels.SignConsortium(consortium, privKey1)
+		require.NoError(t, err)
+
+		conf, err := cs.GetConsortium(cServ.URL, "foo.bar")
+		require.NoError(t, err)
+		require.Equal(t, "foo.bar", conf.Config.Domain)
 	})
 
 	t.Run("success - one stakeholder server disagrees, only one needs to agree", func(t *testing.T) {
@@ -115,11 +177,13 @@ func TestConfigService_GetConsortium(t *testing.T) {
 		}))
 		defer s2Serv.Close()
 
-		var err error
+		privKey1, pubKey1, err := mockmodels.GenerateKey("key1")
+		require.NoError(t, err)
 
 		consortium := mockmodels.DummyConsortium("foo.bar", []models.StakeholderListElement{
 			{
-				Domain: s1Serv.URL,
+				Domain:    s1Serv.URL,
+				PublicKey: pubKey1,
 			},
 			{
 				Domain: s2Serv.URL,
@@ -128,7 +192,7 @@ func TestConfigService_GetConsortium(t *testing.T) {
 		// only require one stakeholder to endorse
 		consortium.Policy.NumQueries = 1
 
-		consortiumFile, err = mockmodels.WrapConsortium(consortium)
+		consortiumFile, err = mockmodels.SignConsortium(consortium, privKey1)
 		require.NoError(t, err)
 
 		cs := NewService(httpconfig.NewService())
@@ -178,3 +242,18 @@ func TestConfigService_GetStakeholder(t *testing.T) {
 		require.Equal(t, conf.Config.Domain, "foo")
 	})
 }
+
+func TestConfigService_GetDIDConfiguration(t *testing.T) {
+	t.Run("pass through", func(t *testing.T) {
+		cs := NewService(&mockconfig.MockConfigService{
+			GetDIDConfigurationFunc: func(url string) (*models.DIDConfiguration, error) {
+				require.Equal(t, "foo.bar", url)
+
+				return &models.DIDConfiguration{}, fmt.Errorf("foo error")
+			}})
+
+		conf, err := cs.GetDIDConfiguration("foo.bar")
+		require.EqualError(t, err, "foo error")
+		require.NotNil(t, conf)
+	})
+}
@@ -0,0 +1,238 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package trustbloc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	docdid "github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+
+	didclient "github.com/trustbloc/trustbloc-did-method/pkg/did"
+)
+
+const (
+	serviceParam     = "service"
+	relativeRefParam = "relativeRef"
+)
+
+//nolint:gochecknoglobals
+var (
+	// ErrFragmentNotFound is returned by Dereference when no verification method or service
+	// matches the fragment of the did url
+	ErrFragmentNotFound = errors.New("did url fragment not found")
+
+	// ErrServiceNotFound is returned by Dereference when no service matches the service parameter of the did url
+	ErrServiceNotFound = errors.New("did url service not found")
+)
+
+// DereferenceResult holds the resource selected by a did url in the resolved did document
+type DereferenceResult struct {
+	// DIDDocument is the resolved did document, it is the selected resource when the did url has no selector
+	DIDDocument *docdid.Doc
+	// PublicKey is the verification method selected by the fragment
+	PublicKey *docdid.PublicKey
+	// Service is the service selected by the service parameter or the fragment
+	Service *docdid.Service
+	// Redirect is the service endpoint with the relative reference (and fragment) of the did url applied
+	Redirect string
+}
+
+// JSONBytes returns the selected resource as it appears in the did document, or the did document itself.
+// There is no content for redirects.
+func (r *DereferenceResult) JSONBytes() ([]byte, error) {
+	switch {
+	case r.Redirect != "":
+		return nil, errors.New("no content for redirect")
+	case r.PublicKey != nil:
+		return resourceJSON(r.DIDDocument, r.PublicKey.ID)
+	case r.Service != nil:
+		return resourceJSON(r.DIDDocument, r.Service.ID)
+	default:
+		return r.DIDDocument.JSONBytes()
+	}
+}
+
+type didURL struct {
+	did         string
+	fragment    string
+	service     string
+	relativeRef string
+}
+
+// Dereference resolves the did of the did url and returns the resource it selects: the verification method
+// or service matching its fragment, the service matching its service parameter, or the service endpoint of
+// that service with the relativeRef parameter applied. The did document is returned for bare dids.
+// ErrFragmentNotFound or ErrServiceNotFound is returned if the selected resource is not in the document.
+func (v *VDRI) Dereference(didURL string, opts ...vdriapi.ResolveOpts) (*DereferenceResult, error) {
+	return v.DereferenceWithContext(context.Background(), didURL, opts...)
+}
+
+// DereferenceWithContext returns the resource selected by the did url in the resolved did document,
+// the discovery and resolution requests are cancelled with the context
+func (v *VDRI) DereferenceWithContext(ctx context.Context, didURL string,
+	opts ...vdriapi.ResolveOpts) (*DereferenceResult, error) {
+	parsed, err := parseDIDURL(didURL)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := v.ResolveWithContext(ctx, parsed.did, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.MethodMetadata.Deactivated {
+		return nil, fmt.Errorf("%w: %s", ErrDIDDeactivated, parsed.did)
+	}
+
+	return dereference(result.DIDDocument, parsed)
+}
+
+// parseDIDURL splits a did url into the did to resolve and its selectors.
+// The initial state of long-form dids is kept in the did.
+func parseDIDURL(rawURL string) (*didURL, error) {
+	parsed := &didURL{did: rawURL}
+
+	if pos := strings.Index(parsed.did, "#"); pos != -1 {
+		parsed.did, parsed.fragment = parsed.did[:pos], parsed.did[pos+1:]
+	}
+
+	rawQuery := ""
+	if pos := strings.Index(parsed.did, "?"); pos != -1 {
+		parsed.did, rawQuery = parsed.did[:pos], parsed.did[pos+1:]
+	}
+
+	if strings.Contains(parsed.did, "/") {
+		return nil, fmt.Errorf("did url path is not supported: %s", rawURL)
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("invalid did url query: %w", err)
+	}
+
+	for name := range query {
+		switch name {
+		case didclient.InitialStateParam:
+			parsed.did += "?" + didclient.InitialStateParam + "=" + query.Get(name)
+		case serviceParam:
+			parsed.service = query.Get(name)
+		case relativeRefParam:
+			parsed.relativeRef = query.Get(name)
+		default:
+			return nil, fmt.Errorf("did url parameter is not supported: %s", name)
+		}
+	}
+
+	if parsed.relativeRef != "" && parsed.service == "" {
+		return nil, fmt.Errorf("did url parameter %s requires the %s parameter", relativeRefParam, serviceParam)
+	}
+
+	return parsed, nil
+}
+
+func dereference(doc *docdid.Doc, parsed *didURL) (*DereferenceResult, error) {
+	if parsed.service != "" {
+		return dereferenceService(doc, parsed)
+	}
+
+	if parsed.fragment == "" {
+		return &DereferenceResult{DIDDocument: doc}, nil
+	}
+
+	for i := range doc.PublicKey {
+		if matchesFragment(doc.PublicKey[i].ID, doc.ID, parsed.fragment) {
+			return &DereferenceResult{DIDDocument: doc, PublicKey: &doc.PublicKey[i]}, nil
+		}
+	}
+
+	for _, methods := range [][]docdid.VerificationMethod{doc.Authentication, doc.AssertionMethod,
+		doc.CapabilityDelegation, doc.CapabilityInvocation, doc.KeyAgreement} {
+		for i := range methods {
+			if methods[i].Embedded && matchesFragment(methods[i].PublicKey.ID, doc.ID, parsed.fragment) {
+				return &DereferenceResult{DIDDocument: doc, PublicKey: &methods[i].PublicKey}, nil
+			}
+		}
+	}
+
+	for i := range doc.Service {
+		if matchesFragment(doc.Service[i].ID, doc.ID, parsed.fragment) {
+			return &DereferenceResult{DIDDocument: doc, Service: &doc.Service[i]}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrFragmentNotFound, parsed.fragment)
+}
+
+func dereferenceService(doc *docdid.Doc, parsed *didURL) (*DereferenceResult, error) {
+	for i := range doc.Service {
+		service := &doc.Service[i]
+
+		if !matchesFragment(service.ID, doc.ID, parsed.service) {
+			continue
+		}
+
+		if parsed.relativeRef == "" && parsed.fragment == "" {
+			return &DereferenceResult{DIDDocument: doc, Service: service}, nil
+		}
+
+		endpoint, err := url.Parse(service.ServiceEndpoint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid service endpoint %s: %w", service.ServiceEndpoint, err)
+		}
+
+		ref, err := url.Parse(parsed.relativeRef)
+		if err != nil {
+			return nil, fmt.Errorf("invalid did url parameter %s: %w", relativeRefParam, err)
+		}
+
+		redirect := endpoint.ResolveReference(ref)
+		if parsed.fragment != "" {
+			redirect.Fragment = parsed.fragment
+		}
+
+		return &DereferenceResult{DIDDocument: doc, Service: service, Redirect: redirect.String()}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrServiceNotFound, parsed.service)
+}
+
+// matchesFragment reports whether the id of a did document resource (absolute or relative) has the fragment
+func matchesFragment(id, did, fragment string) bool {
+	return id == did+"#"+fragment || id == "#"+fragment || id == fragment
+}
+
+// resourceJSON returns the verification method or service with the given id as it appears in the did document
+func resourceJSON(doc *docdid.Doc, id string) ([]byte, error) {
+	docBytes, err := doc.JSONBytes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal did document: %w", err)
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(docBytes, &raw); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal did document: %w", err)
+	}
+
+	for _, property := range []string{"publicKey", "service", "authentication", "assertionMethod",
+		"capabilityDelegation", "capabilityInvocation", "keyAgreement"} {
+		resources, _ := raw[property].([]interface{}) //nolint:errcheck
+
+		for _, r := range resources {
+			if resource, ok := r.(map[string]interface{}); ok && resource["id"] == id {
+				return json.Marshal(resource)
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("resource %s not found in did document", id)
+}
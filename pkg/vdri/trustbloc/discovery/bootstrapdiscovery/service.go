@@ -0,0 +1,274 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bootstrapdiscovery
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec"
+	docdid "github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/trustbloc/trustbloc-did-method/pkg/vdri/trustbloc/models"
+)
+
+type config interface {
+	GetConsortiumWithContext(ctx context.Context, url, domain string) (*models.ConsortiumFileData, error)
+	GetStakeholderWithContext(ctx context.Context, url, domain string) (*models.StakeholderFileData, error)
+	GetDIDConfigurationWithContext(ctx context.Context, url string) (*models.DIDConfiguration, error)
+}
+
+// ResolveFunc resolves a did with the sidetree endpoint at the url
+type ResolveFunc func(ctx context.Context, endpointURL, did string) (*docdid.Doc, error)
+
+// DiscoveryService fetches endpoints for a consortium, from the stakeholders whose DID documents
+// express the keys the consortium lists for them
+type DiscoveryService struct {
+	config  config
+	resolve ResolveFunc
+}
+
+// NewService create new DiscoveryService
+func NewService(c config, resolve ResolveFunc) *DiscoveryService {
+	return &DiscoveryService{config: c, resolve: resolve}
+}
+
+// GetEndpoints get a list of endpoints to use from a consortium domain
+func (ds *DiscoveryService) GetEndpoints(consortiumDomain string) ([]*models.Endpoint, error) {
+	return ds.GetEndpointsWithContext(context.Background(), consortiumDomain)
+}
+
+// GetEndpointsWithContext get a list of endpoints to use from a consortium domain, the config and resolution
+// requests are cancelled with the context.
+// N stakeholders chosen at random are verified, where N is the num_queries consortium policy (all the stakeholders
+// if it is zero): the stakeholder config must be signed with the key listed in the consortium, and that key must be
+// expressed by the DID document of the stakeholder, resolved with one of its endpoints. A stakeholder failing
+// verification is replaced by another one, and discovery fails if fewer than N stakeholders are verified.
+// Only the endpoints of the verified stakeholders are returned.
+func (ds *DiscoveryService) GetEndpointsWithContext(ctx context.Context,
+	consortiumDomain string) ([]*models.Endpoint, error) {
+	consortiumData, err := ds.config.GetConsortiumWithContext(ctx, consortiumDomain, consortiumDomain)
+	if err != nil {
+		return nil, fmt.Errorf("getting consortium: %w", err)
+	}
+
+	consortium := consortiumData.Config
+	if consortium == nil {
+		return nil, fmt.Errorf("consortium config is nil")
+	}
+
+	required := consortium.Policy.NumQueries
+	if required == 0 {
+		required = len(consortium.Members)
+	}
+
+	var endpoints []*models.Endpoint
+
+	verified := 0
+
+	for _, i := range rand.Perm(len(consortium.Members)) {
+		if verified == required {
+			break
+		}
+
+		member := &consortium.Members[i]
+
+		stakeholder, err := ds.verifyStakeholder(ctx, member)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+
+			log.Warnf("stakeholder %s failed bootstrap verification: %s", member.Domain, err)
+
+			continue
+		}
+
+		// label the endpoints with the member domain, not the domain declared by the stakeholder config
+		for _, ep := range stakeholder.Endpoints {
+			endpoints = append(endpoints, &models.Endpoint{URL: ep, Domain: member.Domain})
+		}
+
+		verified++
+	}
+
+	if verified < required {
+		return nil, fmt.Errorf("bootstrap: %d of %d required stakeholders verified", verified, required)
+	}
+
+	return endpoints, nil
+}
+
+// verifyStakeholder fetches the config of a consortium member and verifies it against the member key,
+// then checks that the key is expressed by the member DID document and that the DID is linked to the domain
+func (ds *DiscoveryService) verifyStakeholder(ctx context.Context,
+	member *models.StakeholderListElement) (*models.Stakeholder, error) {
+	if member.DID == "" || member.PublicKey == nil {
+		return nil, errors.New("the consortium lists no did or public key for the stakeholder")
+	}
+
+	stakeholderData, err := ds.config.GetStakeholderWithContext(ctx, member.Domain, member.Domain)
+	if err != nil {
+		return nil, err
+	}
+
+	err = stakeholderData.VerifySignature(member.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if stakeholderData.Config.DID != member.DID {
+		return nil, fmt.Errorf("stakeholder config did %s doesn't match %s", stakeholderData.Config.DID, member.DID)
+	}
+
+	doc, err := ds.resolveStakeholderDID(ctx, member.DID, stakeholderData.Config.Endpoints)
+	if err != nil {
+		return nil, err
+	}
+
+	err = verifyPublicKey(doc, member)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ds.verifyDomainLinkage(ctx, member)
+	if err != nil {
+		return nil, err
+	}
+
+	return stakeholderData.Config, nil
+}
+
+// verifyDomainLinkage checks that the DID configuration of the stakeholder domain links the member DID
+// to the domain, with an assertion signed by the member key
+func (ds *DiscoveryService) verifyDomainLinkage(ctx context.Context, member *models.StakeholderListElement) error {
+	didConfig, err := ds.config.GetDIDConfigurationWithContext(ctx, member.Domain)
+	if err != nil {
+		return err
+	}
+
+	return didConfig.VerifyMemberLinkage(member)
+}
+
+// resolveStakeholderDID resolves the stakeholder DID with the first of its endpoints that succeeds
+func (ds *DiscoveryService) resolveStakeholderDID(ctx context.Context, did string,
+	endpoints []string) (*docdid.Doc, error) {
+	err := errors.New("the stakeholder has no endpoints")
+
+	for _, ep := range endpoints {
+		var doc *docdid.Doc
+
+		doc, err = ds.resolve(ctx, ep, did)
+		if err == nil {
+			return doc, nil
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return nil, fmt.Errorf("failed to resolve stakeholder did %s: %w", did, err)
+}
+
+// verifyPublicKey checks that the member key is a key of the member DID document, with the same id and value
+func verifyPublicKey(doc *docdid.Doc, member *models.StakeholderListElement) error {
+	keyID := member.PublicKey.ID
+
+	pos := strings.Index(keyID, "#")
+	if pos == -1 || keyID[:pos] != member.DID {
+		return fmt.Errorf("public key id %s is not a did url of the stakeholder did %s", keyID, member.DID)
+	}
+
+	fragment := keyID[pos+1:]
+
+	for i := range doc.PublicKey {
+		pk := &doc.PublicKey[i]
+
+		if pk.ID != keyID && pk.ID != "#"+fragment && pk.ID != fragment {
+			continue
+		}
+
+		match, err := samePublicKey(pk, member.PublicKey)
+		if err != nil {
+			return err
+		}
+
+		if !match {
+			return fmt.Errorf("public key %s doesn't match the key of the stakeholder did document", keyID)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("public key %s not found in the stakeholder did document", keyID)
+}
+
+// samePublicKey reports whether the did document public key is the key of the JWK. EC keys are compared
+// by curve and coordinates, as the document may express them uncompressed, compressed (secp256k1) or as a JWK.
+func samePublicKey(pk *docdid.PublicKey, key *models.PublicKey) (bool, error) {
+	if key.JWK == nil {
+		return false, fmt.Errorf("public key %s has no jwk", key.ID)
+	}
+
+	switch want := key.JWK.Key.(type) {
+	case ed25519.PublicKey:
+		return bytes.Equal(pk.Value, want), nil
+	case *ecdsa.PublicKey:
+		got, err := ecdsaPublicKey(pk, want.Curve)
+		if err != nil {
+			return false, fmt.Errorf("public key %s of the stakeholder did document: %w", pk.ID, err)
+		}
+
+		return sameCurve(got.Curve, want.Curve) && got.X.Cmp(want.X) == 0 && got.Y.Cmp(want.Y) == 0, nil
+	default:
+		return false, fmt.Errorf("public key %s has unsupported type %T", key.ID, key.JWK.Key)
+	}
+}
+
+// ecdsaPublicKey returns the EC key of a did document public key from its JWK, or from its raw value
+// on the given curve
+func ecdsaPublicKey(pk *docdid.PublicKey, curve elliptic.Curve) (*ecdsa.PublicKey, error) {
+	if jwk := pk.JSONWebKey(); jwk != nil {
+		key, ok := jwk.Key.(*ecdsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("not an EC key: %T", jwk.Key)
+		}
+
+		return key, nil
+	}
+
+	if sameCurve(curve, btcec.S256()) {
+		key, err := btcec.ParsePubKey(pk.Value, btcec.S256())
+		if err != nil {
+			return nil, err
+		}
+
+		return key.ToECDSA(), nil
+	}
+
+	x, y := elliptic.Unmarshal(curve, pk.Value)
+	if x == nil {
+		return nil, errors.New("invalid EC point")
+	}
+
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+func sameCurve(a, b elliptic.Curve) bool {
+	pa, pb := a.Params(), b.Params()
+
+	return pa.P.Cmp(pb.P) == 0 && pa.N.Cmp(pb.N) == 0 && pa.Gx.Cmp(pb.Gx) == 0 && pa.Gy.Cmp(pb.Gy) == 0
+}
@@ -0,0 +1,502 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bootstrapdiscovery
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	docdid "github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/square/go-jose"
+	"github.com/stretchr/testify/require"
+
+	mockconfig "github.com/trustbloc/trustbloc-did-method/pkg/internal/mock/config"
+	mockmodels "github.com/trustbloc/trustbloc-did-method/pkg/internal/mock/models"
+	"github.com/trustbloc/trustbloc-did-method/pkg/vdri/trustbloc/models"
+)
+
+type stakeholder struct {
+	member    models.StakeholderListElement
+	config    *models.Stakeholder
+	privKey   *jose.JSONWebKey
+	doc       *docdid.Doc
+	didConfig *models.DIDConfiguration
+}
+
+// newStakeholder creates a stakeholder with a signing key expressed by its did document,
+// and a did configuration linking its did to its domain
+func newStakeholder(t *testing.T, domain string) *stakeholder {
+	did := "did:trustbloc:foo.bar:" + domain
+	keyID := did + "#key1"
+
+	privKey, pubKey, err := mockmodels.GenerateKey(keyID)
+	require.NoError(t, err)
+
+	didConfig, err := mockmodels.DIDConfiguration(did, domain, privKey)
+	require.NoError(t, err)
+
+	config := mockmodels.DummyStakeholder(domain, []string{"https://" + domain + "/1", "https://" + domain + "/2"})
+	config.DID = did
+
+	return &stakeholder{
+		member:  models.StakeholderListElement{Domain: domain, DID: did, PublicKey: pubKey},
+		config:  config,
+		privKey: privKey,
+		doc: &docdid.Doc{ID: did, PublicKey: []docdid.PublicKey{
+			*docdid.NewPublicKeyFromBytes("#key1", "Ed25519VerificationKey2018", did,
+				pubKey.JWK.Key.(ed25519.PublicKey)),
+		}},
+		didConfig: didConfig,
+	}
+}
+
+// newService returns a discovery service for a consortium of the stakeholders, resolving their did documents
+func newService(t *testing.T, numQueries int, stakeholders ...*stakeholder) *DiscoveryService {
+	var members []models.StakeholderListElement
+
+	files := map[string]*models.StakeholderFileData{}
+	docs := map[string]*docdid.Doc{}
+	didConfigs := map[string]*models.DIDConfiguration{}
+
+	for _, s := range stakeholders {
+		members = append(members, s.member)
+
+		file, err := mockmodels.SignStakeholder(s.config, s.privKey)
+		require.NoError(t, err)
+
+		files[s.member.Domain], err = models.ParseStakeholder([]byte(file))
+		require.NoError(t, err)
+
+		for _, ep := range s.config.Endpoints {
+			docs[ep+" "+s.member.DID] = s.doc
+		}
+
+		didConfigs[s.member.Domain] = s.didConfig
+	}
+
+	consortium := mockmodels.DummyConsortium("foo.bar", members)
+	consortium.Policy.NumQueries = numQueries
+
+	return NewService(&mockconfig.MockConfigService{
+		GetConsortiumFunc: func(string, string) (*models.ConsortiumFileData, error) {
+			return &models.ConsortiumFileData{Config: consortium}, nil
+		},
+		GetStakeholderFunc: func(url, _ string) (*models.StakeholderFileData, error) {
+			if file, ok := files[url]; ok {
+				return file, nil
+			}
+
+			return nil, fmt.Errorf("stakeholder %s not found", url)
+		},
+		GetDIDConfigurationFunc: func(url string) (*models.DIDConfiguration, error) {
+			if didConfig, ok := didConfigs[url]; ok {
+				return didConfig, nil
+			}
+
+			return nil, fmt.Errorf("did configuration of %s not found", url)
+		},
+	}, func(_ context.Context, url, did string) (*docdid.Doc, error) {
+		if doc, ok := docs[url+" "+did]; ok {
+			return doc, nil
+		}
+
+		return nil, fmt.Errorf("did %s not found at %s", did, url)
+	})
+}
+
+func TestDiscoveryService_GetEndpoints(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		s := newService(t, 0, newStakeholder(t, "bar.baz"), newStakeholder(t, "baz.qux"))
+
+		endpoints, err := s.GetEndpoints("foo.bar")
+		require.NoError(t, err)
+		require.Len(t, endpoints, 4)
+	})
+
+	t.Run("success: endpoints are labelled with the member domain", func(t *testing.T) {
+		impostor := newStakeholder(t, "baz.qux")
+		impostor.config.Domain = "bar.baz"
+
+		s := newService(t, 0, newStakeholder(t, "bar.baz"), impostor)
+
+		endpoints, err := s.GetEndpoints("foo.bar")
+		require.NoError(t, err)
+		require.Len(t, endpoints, 4)
+
+		for _, ep := range endpoints {
+			require.True(t, strings.HasPrefix(ep.URL, "https://"+ep.Domain+"/"), ep.URL)
+		}
+	})
+
+	t.Run("success: failing stakeholders are replaced", func(t *testing.T) {
+		noKey := newStakeholder(t, "no.key")
+		noKey.doc.PublicKey = nil
+
+		for i := 0; i < 10; i++ {
+			s := newService(t, 2, noKey, newStakeholder(t, "bar.baz"), newStakeholder(t, "baz.qux"))
+
+			endpoints, err := s.GetEndpoints("foo.bar")
+			require.NoError(t, err)
+			require.Len(t, endpoints, 4)
+
+			for _, ep := range endpoints {
+				require.NotEqual(t, "no.key", ep.Domain)
+			}
+		}
+	})
+
+	t.Run("success: stakeholder did resolved with another endpoint", func(t *testing.T) {
+		sh := newStakeholder(t, "bar.baz")
+
+		s := newService(t, 0, sh)
+
+		resolve := s.resolve
+		s.resolve = func(ctx context.Context, url, did string) (*docdid.Doc, error) {
+			if url == sh.config.Endpoints[0] {
+				return nil, errors.New("endpoint down")
+			}
+
+			return resolve(ctx, url, did)
+		}
+
+		endpoints, err := s.GetEndpoints("foo.bar")
+		require.NoError(t, err)
+		require.Len(t, endpoints, 2)
+	})
+
+	t.Run("failure: too few stakeholders verified", func(t *testing.T) {
+		noKey := newStakeholder(t, "no.key")
+		noKey.doc.PublicKey = nil
+
+		s := newService(t, 0, noKey, newStakeholder(t, "bar.baz"))
+
+		_, err := s.GetEndpoints("foo.bar")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "bootstrap: 1 of 2 required stakeholders verified")
+	})
+
+	t.Run("failure: consortium", func(t *testing.T) {
+		s := NewService(&mockconfig.MockConfigService{
+			GetConsortiumFunc: func(string, string) (*models.ConsortiumFileData, error) {
+				return nil, errors.New("consortium error")
+			}}, nil)
+
+		_, err := s.GetEndpoints("foo.bar")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "consortium error")
+
+		s = NewService(&mockconfig.MockConfigService{
+			GetConsortiumFunc: func(string, string) (*models.ConsortiumFileData, error) {
+				return &models.ConsortiumFileData{}, nil
+			}}, nil)
+
+		_, err = s.GetEndpoints("foo.bar")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "consortium config is nil")
+	})
+
+	t.Run("failure: context cancelled", func(t *testing.T) {
+		s := newService(t, 0, newStakeholder(t, "bar.baz"))
+		s.resolve = func(ctx context.Context, _, _ string) (*docdid.Doc, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := s.GetEndpointsWithContext(ctx, "foo.bar")
+		require.True(t, errors.Is(err, context.Canceled))
+	})
+}
+
+func TestDiscoveryService_verifyStakeholder(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		sh := newStakeholder(t, "bar.baz")
+
+		config, err := newService(t, 0, sh).verifyStakeholder(context.Background(), &sh.member)
+		require.NoError(t, err)
+		require.Equal(t, sh.config.Endpoints, config.Endpoints)
+	})
+
+	t.Run("failure: no did or public key", func(t *testing.T) {
+		sh := newStakeholder(t, "bar.baz")
+		s := newService(t, 0, sh)
+
+		_, err := s.verifyStakeholder(context.Background(), &models.StakeholderListElement{Domain: "bar.baz",
+			PublicKey: sh.member.PublicKey})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "no did or public key")
+
+		_, err = s.verifyStakeholder(context.Background(), &models.StakeholderListElement{Domain: "bar.baz",
+			DID: sh.member.DID})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "no did or public key")
+	})
+
+	t.Run("failure: stakeholder config", func(t *testing.T) {
+		sh := newStakeholder(t, "bar.baz")
+		member := sh.member
+		member.Domain = "other.domain"
+
+		_, err := newService(t, 0, sh).verifyStakeholder(context.Background(), &member)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "stakeholder other.domain not found")
+	})
+
+	t.Run("failure: stakeholder config signed with another key", func(t *testing.T) {
+		sh := newStakeholder(t, "bar.baz")
+		sh.privKey, _, _ = mockmodels.GenerateKey(sh.member.PublicKey.ID) //nolint:errcheck
+
+		_, err := newService(t, 0, sh).verifyStakeholder(context.Background(), &sh.member)
+		require.True(t, errors.Is(err, models.ErrInvalidSignature))
+	})
+
+	t.Run("failure: stakeholder config for another did", func(t *testing.T) {
+		sh := newStakeholder(t, "bar.baz")
+		sh.config.DID = "did:trustbloc:foo.bar:other"
+
+		_, err := newService(t, 0, sh).verifyStakeholder(context.Background(), &sh.member)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "stakeholder config did did:trustbloc:foo.bar:other doesn't match")
+	})
+
+	t.Run("failure: stakeholder did not resolved", func(t *testing.T) {
+		sh := newStakeholder(t, "bar.baz")
+		s := newService(t, 0, sh)
+		s.resolve = func(context.Context, string, string) (*docdid.Doc, error) {
+			return nil, errors.New("endpoint down")
+		}
+
+		_, err := s.verifyStakeholder(context.Background(), &sh.member)
+		require.Error(t, err)
+		require.Contains(t, err.Error(),
+			"failed to resolve stakeholder did did:trustbloc:foo.bar:bar.baz: endpoint down")
+
+		_, err = s.resolveStakeholderDID(context.Background(), sh.member.DID, nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "the stakeholder has no endpoints")
+	})
+}
+
+func TestDiscoveryService_verifyDomainLinkage(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		sh := newStakeholder(t, "bar.baz")
+
+		require.NoError(t, newService(t, 0, sh).verifyDomainLinkage(context.Background(), &sh.member))
+	})
+
+	t.Run("failure: did configuration not found", func(t *testing.T) {
+		sh := newStakeholder(t, "bar.baz")
+
+		s := newService(t, 0, sh)
+		s.config.(*mockconfig.MockConfigService).GetDIDConfigurationFunc = func(string) (*models.DIDConfiguration,
+			error) {
+			return nil, errors.New("did configuration request failed")
+		}
+
+		_, err := s.verifyStakeholder(context.Background(), &sh.member)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "did configuration request failed")
+	})
+
+	t.Run("failure: did linked to another domain", func(t *testing.T) {
+		sh := newStakeholder(t, "bar.baz")
+
+		var err error
+
+		sh.didConfig, err = mockmodels.DIDConfiguration(sh.member.DID, "other.domain", sh.privKey)
+		require.NoError(t, err)
+
+		_, err = newService(t, 0, sh).verifyStakeholder(context.Background(), &sh.member)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "domain 'other.domain' doesn't match 'bar.baz'")
+	})
+
+	t.Run("failure: linkage signed with another key", func(t *testing.T) {
+		sh := newStakeholder(t, "bar.baz")
+
+		otherKey, _, err := mockmodels.GenerateKey(sh.member.PublicKey.ID)
+		require.NoError(t, err)
+
+		sh.didConfig, err = mockmodels.DIDConfiguration(sh.member.DID, "bar.baz", otherKey)
+		require.NoError(t, err)
+
+		err = newService(t, 0, sh).verifyDomainLinkage(context.Background(), &sh.member)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), models.ErrInvalidSignature.Error())
+	})
+}
+
+func TestVerifyPublicKey(t *testing.T) {
+	sh := newStakeholder(t, "bar.baz")
+
+	t.Run("success", func(t *testing.T) {
+		require.NoError(t, verifyPublicKey(sh.doc, &sh.member))
+
+		// absolute key id in the did document
+		doc := *sh.doc
+		doc.PublicKey = []docdid.PublicKey{*docdid.NewPublicKeyFromBytes(sh.member.PublicKey.ID,
+			"Ed25519VerificationKey2018", sh.member.DID, sh.doc.PublicKey[0].Value)}
+
+		require.NoError(t, verifyPublicKey(&doc, &sh.member))
+	})
+
+	t.Run("success: ecdsa key", func(t *testing.T) {
+		priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		member := sh.member
+		member.PublicKey = &models.PublicKey{ID: sh.member.PublicKey.ID, JWK: &jose.JSONWebKey{Key: &priv.PublicKey}}
+
+		doc := &docdid.Doc{ID: sh.member.DID, PublicKey: []docdid.PublicKey{*docdid.NewPublicKeyFromBytes("key1",
+			"JwsVerificationKey2020", sh.member.DID, elliptic.Marshal(priv.Curve, priv.X, priv.Y))}}
+
+		require.NoError(t, verifyPublicKey(doc, &member))
+	})
+
+	t.Run("success: secp256k1 key", func(t *testing.T) {
+		priv, err := btcec.NewPrivateKey(btcec.S256())
+		require.NoError(t, err)
+
+		member := sh.member
+		member.PublicKey = &models.PublicKey{ID: sh.member.PublicKey.ID,
+			JWK: &jose.JSONWebKey{Key: priv.PubKey().ToECDSA()}}
+
+		// compressed point, as published by sidetree and aries
+		doc := &docdid.Doc{ID: sh.member.DID, PublicKey: []docdid.PublicKey{*docdid.NewPublicKeyFromBytes("key1",
+			"EcdsaSecp256k1VerificationKey2019", sh.member.DID, priv.PubKey().SerializeCompressed())}}
+
+		require.NoError(t, verifyPublicKey(doc, &member))
+
+		doc.PublicKey[0].Value = priv.PubKey().SerializeUncompressed()
+
+		require.NoError(t, verifyPublicKey(doc, &member))
+
+		// jwk of the did document
+		require.NoError(t, verifyPublicKey(secp256k1JWKDoc(t, sh.member.DID, priv.PubKey()), &member))
+	})
+
+	t.Run("success: ecdsa key as jwk", func(t *testing.T) {
+		priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		member := sh.member
+		member.PublicKey = &models.PublicKey{ID: sh.member.PublicKey.ID, JWK: &jose.JSONWebKey{Key: &priv.PublicKey}}
+
+		jwk, err := (&jose.JSONWebKey{Key: &priv.PublicKey}).MarshalJSON()
+		require.NoError(t, err)
+
+		doc, err := docdid.ParseDocument([]byte(fmt.Sprintf(`{"@context":["https://w3id.org/did/v1"],"id":"%s",
+			"publicKey":[{"id":"#key1","type":"JwsVerificationKey2020","controller":"%s","publicKeyJwk":%s}]}`,
+			sh.member.DID, sh.member.DID, jwk)))
+		require.NoError(t, err)
+
+		require.NoError(t, verifyPublicKey(doc, &member))
+	})
+
+	t.Run("failure: ecdsa key doesn't match the did document", func(t *testing.T) {
+		priv, err := btcec.NewPrivateKey(btcec.S256())
+		require.NoError(t, err)
+
+		// same coordinates on another curve
+		member := sh.member
+		member.PublicKey = &models.PublicKey{ID: sh.member.PublicKey.ID, JWK: &jose.JSONWebKey{
+			Key: &ecdsa.PublicKey{Curve: elliptic.P256(), X: priv.PubKey().X, Y: priv.PubKey().Y}}}
+
+		err = verifyPublicKey(secp256k1JWKDoc(t, sh.member.DID, priv.PubKey()), &member)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "doesn't match the key of the stakeholder did document")
+
+		doc := &docdid.Doc{ID: sh.member.DID, PublicKey: []docdid.PublicKey{*docdid.NewPublicKeyFromBytes("key1",
+			"JwsVerificationKey2020", sh.member.DID, priv.PubKey().SerializeUncompressed())}}
+
+		err = verifyPublicKey(doc, &member)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid EC point")
+
+		member.PublicKey.JWK = &jose.JSONWebKey{Key: priv.PubKey().ToECDSA()}
+		doc.PublicKey[0].Value = []byte("not a point")
+
+		err = verifyPublicKey(doc, &member)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "public key key1 of the stakeholder did document")
+	})
+
+	t.Run("failure: key id isn't a did url of the stakeholder did", func(t *testing.T) {
+		member := sh.member
+		member.PublicKey = &models.PublicKey{ID: "did:trustbloc:foo.bar:other#key1", JWK: sh.member.PublicKey.JWK}
+
+		err := verifyPublicKey(sh.doc, &member)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "is not a did url of the stakeholder did")
+
+		member.PublicKey = &models.PublicKey{ID: "key1", JWK: sh.member.PublicKey.JWK}
+
+		err = verifyPublicKey(sh.doc, &member)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "is not a did url of the stakeholder did")
+	})
+
+	t.Run("failure: key not in did document", func(t *testing.T) {
+		member := sh.member
+		member.PublicKey = &models.PublicKey{ID: sh.member.DID + "#key2", JWK: sh.member.PublicKey.JWK}
+
+		err := verifyPublicKey(sh.doc, &member)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not found in the stakeholder did document")
+	})
+
+	t.Run("failure: key doesn't match the did document", func(t *testing.T) {
+		_, other, err := mockmodels.GenerateKey(sh.member.PublicKey.ID)
+		require.NoError(t, err)
+
+		member := sh.member
+		member.PublicKey = other
+
+		err = verifyPublicKey(sh.doc, &member)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "doesn't match the key of the stakeholder did document")
+	})
+
+	t.Run("failure: unsupported key", func(t *testing.T) {
+		member := sh.member
+		member.PublicKey = &models.PublicKey{ID: sh.member.PublicKey.ID}
+
+		err := verifyPublicKey(sh.doc, &member)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "has no jwk")
+
+		member.PublicKey = &models.PublicKey{ID: sh.member.PublicKey.ID, JWK: &jose.JSONWebKey{Key: []byte("secret")}}
+
+		err = verifyPublicKey(sh.doc, &member)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "has unsupported type []uint8")
+	})
+}
+
+// secp256k1JWKDoc returns a did document expressing the secp256k1 key as JWK
+func secp256k1JWKDoc(t *testing.T, did string, key *btcec.PublicKey) *docdid.Doc {
+	point := key.SerializeUncompressed()
+
+	doc, err := docdid.ParseDocument([]byte(fmt.Sprintf(`{"@context":["https://w3id.org/did/v1"],"id":"%s",
+		"publicKey":[{"id":"#key1","type":"EcdsaSecp256k1VerificationKey2019","controller":"%s",
+		"publicKeyJwk":{"kty":"EC","crv":"secp256k1","x":"%s","y":"%s"}}]}`, did, did,
+		base64.RawURLEncoding.EncodeToString(point[1:33]), base64.RawURLEncoding.EncodeToString(point[33:]))))
+	require.NoError(t, err)
+
+	return doc
+}
@@ -7,14 +7,16 @@ SPDX-License-Identifier: Apache-2.0
 package staticdiscovery
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/trustbloc/trustbloc-did-method/pkg/vdri/trustbloc/models"
 )
 
 type config interface {
-	GetConsortium(url, domain string) (*models.ConsortiumFileData, error)
-	GetStakeholder(url, domain string) (*models.StakeholderFileData, error)
+	GetConsortiumWithContext(ctx context.Context, url, domain string) (*models.ConsortiumFileData, error)
+	GetStakeholderWithContext(ctx context.Context, url, domain string) (*models.StakeholderFileData, error)
+	GetDIDConfigurationWithContext(ctx context.Context, url string) (*models.DIDConfiguration, error)
 }
 
 // DiscoveryService fetches endpoints for a consortium
@@ -33,7 +35,14 @@ func NewService(c config) *DiscoveryService {
 
 // GetEndpoints get a list of endpoints to use from a consortium domain
 func (ds *DiscoveryService) GetEndpoints(consortiumDomain string) ([]*models.Endpoint, error) {
-	consortiumData, err := ds.config.GetConsortium(consortiumDomain, consortiumDomain)
+	return ds.GetEndpointsWithContext(context.Background(), consortiumDomain)
+}
+
+// GetEndpointsWithContext get a list of endpoints to use from a consortium domain,
+// the config requests are cancelled with the context
+func (ds *DiscoveryService) GetEndpointsWithContext(ctx context.Context,
+	consortiumDomain string) ([]*models.Endpoint, error) {
+	consortiumData, err := ds.config.GetConsortiumWithContext(ctx, consortiumDomain, consortiumDomain)
 	if err != nil {
 		return nil, fmt.Errorf("getting consortium: %w", err)
 	}
@@ -43,42 +52,54 @@ func (ds *DiscoveryService) GetEndpoints(consortiumDomain string) ([]*models.End
 		return nil, fmt.Errorf("consortium config is nil")
 	}
 
-	stakeholders, err := ds.getStakeholderConfigs(consortium)
+	endpoints, err := ds.getStakeholderEndpoints(ctx, consortium)
 	if err != nil {
 		return nil, fmt.Errorf("stakeholder config: %w", err)
 	}
 
-	return ds.getEndpointsFromStakeholders(stakeholders), nil
+	return endpoints, nil
 }
 
-// getStakeholderConfigs gets the list of stakeholder configs
-func (ds *DiscoveryService) getStakeholderConfigs(consortium *models.Consortium) ([]models.StakeholderFileData, error) { // nolint: lll
-	var stakeholders []models.StakeholderFileData
+// getStakeholderEndpoints gets the endpoints of the stakeholder configs verified against the keys listed in the
+// consortium, from the stakeholders whose DID configuration links their DID to their domain. The endpoints are labelled with the member domain listed in the consortium, not the domain
+// declared by the stakeholder config, so that a stakeholder can't pass for another one during selection.
+func (ds *DiscoveryService) getStakeholderEndpoints(ctx context.Context,
+	consortium *models.Consortium) ([]*models.Endpoint, error) {
+	var endpoints []*models.Endpoint
 
-	for _, s := range consortium.Members {
-		stakeholderConfig, err := ds.config.GetStakeholder(s.Domain, s.Domain)
+	for i := range consortium.Members {
+		member := &consortium.Members[i]
+
+		stakeholderConfig, err := ds.config.GetStakeholderWithContext(ctx, member.Domain, member.Domain)
 		if err != nil {
 			return nil, err
 		}
 
-		stakeholders = append(stakeholders, *stakeholderConfig)
-	}
-
-	return stakeholders, nil
-}
+		err = stakeholderConfig.VerifySignature(member.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("stakeholder %s: %w", member.Domain, err)
+		}
 
-// getEndpointsFromStakeholders constructs the list of endpoints from the data in the list of stakeholders
-func (ds *DiscoveryService) getEndpointsFromStakeholders(stakeholders []models.StakeholderFileData) []*models.Endpoint {
-	var endpoints []*models.Endpoint
+		err = ds.verifyDomainLinkage(ctx, member)
+		if err != nil {
+			return nil, fmt.Errorf("stakeholder %s: %w", member.Domain, err)
+		}
 
-	for _, stakeholderConfig := range stakeholders {
 		for _, ep := range stakeholderConfig.Config.Endpoints {
-			endpoints = append(endpoints, &models.Endpoint{
-				URL:    ep,
-				Domain: stakeholderConfig.Config.Domain,
-			})
+			endpoints = append(endpoints, &models.Endpoint{URL: ep, Domain: member.Domain})
 		}
 	}
 
-	return endpoints
+	return endpoints, nil
+}
+
+// verifyDomainLinkage checks that the DID configuration of the stakeholder domain links the member DID
+// to the domain, with an assertion signed by the member key
+func (ds *DiscoveryService) verifyDomainLinkage(ctx context.Context, member *models.StakeholderListElement) error {
+	didConfig, err := ds.config.GetDIDConfigurationWithContext(ctx, member.Domain)
+	if err != nil {
+		return err
+	}
+
+	return didConfig.VerifyMemberLinkage(member)
 }
@@ -7,6 +7,7 @@ package staticdiscovery
 
 import (
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -21,30 +22,49 @@ import (
 
 func TestDiscoveryService_GetEndpoints(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
-		shFile1, err := mockmodels.DummyStakeholderJSON("bar.baz", []string{
-			"https://bar.baz/webapi/123456", "https://bar.baz/webapi/654321"})
+		privKey1, pubKey1, err := mockmodels.GenerateKey("key1")
 		require.NoError(t, err)
 
-		stakeholderServ1 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			fmt.Fprint(w, shFile1)
-		}))
+		shFile1, err := mockmodels.SignStakeholder(mockmodels.DummyStakeholder("bar.baz", []string{
+			"https://bar.baz/webapi/123456", "https://bar.baz/webapi/654321"}), privKey1)
+		require.NoError(t, err)
+
+		didConfig1, err := mockmodels.DIDConfiguration("did:trustbloc:foo.bar:1", "127.0.0.1", privKey1)
+		require.NoError(t, err)
+
+		handler1, err := mockmodels.StakeholderHandler(shFile1, didConfig1)
+		require.NoError(t, err)
+
+		stakeholderServ1 := httptest.NewServer(handler1)
 		defer stakeholderServ1.Close()
 
-		shFile2, err := mockmodels.DummyStakeholderJSON("baz.qux", []string{
-			"https://baz.qux/iyoubhlkn/", "https://baz.foo/ukjhjtfyw/"})
+		privKey2, pubKey2, err := mockmodels.GenerateKey("key2")
 		require.NoError(t, err)
 
-		stakeholderServ2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			fmt.Fprint(w, shFile2)
-		}))
+		// the second stakeholder declares the domain of the first one
+		shFile2, err := mockmodels.SignStakeholder(mockmodels.DummyStakeholder("bar.baz", []string{
+			"https://baz.qux/iyoubhlkn/", "https://baz.foo/ukjhjtfyw/"}), privKey2)
+		require.NoError(t, err)
+
+		didConfig2, err := mockmodels.DIDConfiguration("did:trustbloc:foo.bar:2", "127.0.0.1", privKey2)
+		require.NoError(t, err)
+
+		handler2, err := mockmodels.StakeholderHandler(shFile2, didConfig2)
+		require.NoError(t, err)
+
+		stakeholderServ2 := httptest.NewServer(handler2)
 		defer stakeholderServ2.Close()
 
 		consortiumData, err := mockmodels.DummyConsortiumJSON("foo.bar", []models.StakeholderListElement{
 			{
-				Domain: stakeholderServ1.URL,
+				Domain:    stakeholderServ1.URL,
+				DID:       "did:trustbloc:foo.bar:1",
+				PublicKey: pubKey1,
 			},
 			{
-				Domain: stakeholderServ2.URL,
+				Domain:    stakeholderServ2.URL,
+				DID:       "did:trustbloc:foo.bar:2",
+				PublicKey: pubKey2,
 			},
 		})
 		require.NoError(t, err)
@@ -58,6 +78,14 @@ func TestDiscoveryService_GetEndpoints(t *testing.T) {
 		endpoints, err := s.GetEndpoints(consortiumServ.URL)
 		require.NoError(t, err)
 		require.Len(t, endpoints, 4)
+
+		domains := map[string]string{}
+		for _, ep := range endpoints {
+			domains[ep.URL] = ep.Domain
+		}
+
+		require.Equal(t, stakeholderServ1.URL, domains["https://bar.baz/webapi/123456"])
+		require.Equal(t, stakeholderServ2.URL, domains["https://baz.qux/iyoubhlkn/"])
 	})
 
 	t.Run("failure: stakeholder server failure", func(t *testing.T) {
@@ -83,4 +111,124 @@ func TestDiscoveryService_GetEndpoints(t *testing.T) {
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "stakeholder config request failed")
 	})
+
+	t.Run("failure: stakeholder config signature doesn't verify", func(t *testing.T) {
+		privKey, pubKey, err := mockmodels.GenerateKey("key1")
+		require.NoError(t, err)
+
+		_, otherKey, err := mockmodels.GenerateKey("key1")
+		require.NoError(t, err)
+
+		stakeholder := mockmodels.DummyStakeholder("bar.baz", []string{"https://bar.baz/webapi/123456"})
+
+		signed, err := mockmodels.SignStakeholder(stakeholder, privKey)
+		require.NoError(t, err)
+
+		unsigned, err := mockmodels.WrapStakeholder(stakeholder)
+		require.NoError(t, err)
+
+		tests := []struct {
+			name        string
+			file        string
+			key         *models.PublicKey
+			expectedErr string
+		}{
+			{name: "unsigned", file: unsigned, key: pubKey, expectedErr: "key id '' doesn't match"},
+			{name: "other key", file: signed, key: otherKey, expectedErr: "square/go-jose"},
+			{name: "no key", file: signed, expectedErr: "missing or invalid public key"},
+		}
+
+		for _, tc := range tests {
+			file := tc.file
+
+			stakeholderServ := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, file)
+			}))
+
+			consortiumFile, err := mockmodels.DummyConsortiumJSON("foo.bar", []models.StakeholderListElement{
+				{
+					Domain:    stakeholderServ.URL,
+					PublicKey: tc.key,
+				},
+			})
+			require.NoError(t, err)
+
+			consortiumServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, consortiumFile)
+			}))
+
+			s := NewService(httpconfig.NewService(httpconfig.WithTLSConfig(&tls.Config{})))
+			_, err = s.GetEndpoints(consortiumServer.URL)
+			require.Error(t, err, tc.name)
+			require.True(t, errors.Is(err, models.ErrInvalidSignature), tc.name)
+			require.Contains(t, err.Error(), tc.expectedErr, tc.name)
+
+			consortiumServer.Close()
+			stakeholderServ.Close()
+		}
+	})
+	t.Run("failure: stakeholder domain linkage doesn't verify", func(t *testing.T) {
+		const did = "did:trustbloc:foo.bar:1"
+
+		privKey, pubKey, err := mockmodels.GenerateKey("key1")
+		require.NoError(t, err)
+
+		otherKey, _, err := mockmodels.GenerateKey("key1")
+		require.NoError(t, err)
+
+		signed, err := mockmodels.SignStakeholder(mockmodels.DummyStakeholder("bar.baz",
+			[]string{"https://bar.baz/webapi/123456"}), privKey)
+		require.NoError(t, err)
+
+		linked, err := mockmodels.DIDConfiguration(did, "127.0.0.1", privKey)
+		require.NoError(t, err)
+
+		otherDomain, err := mockmodels.DIDConfiguration(did, "other.domain", privKey)
+		require.NoError(t, err)
+
+		otherSigner, err := mockmodels.DIDConfiguration(did, "127.0.0.1", otherKey)
+		require.NoError(t, err)
+
+		tests := []struct {
+			name        string
+			did         string
+			didConfig   *models.DIDConfiguration
+			expectedErr string
+		}{
+			{name: "no assertion", did: did, didConfig: &models.DIDConfiguration{},
+				expectedErr: "no domain linkage assertion for did " + did},
+			{name: "other domain", did: did, didConfig: otherDomain,
+				expectedErr: "domain 'other.domain' doesn't match '127.0.0.1'"},
+			{name: "other signer", did: did, didConfig: otherSigner, expectedErr: models.ErrInvalidSignature.Error()},
+			{name: "no did", didConfig: linked, expectedErr: "the consortium lists no did for stakeholder"},
+		}
+
+		for _, tc := range tests {
+			handler, err := mockmodels.StakeholderHandler(signed, tc.didConfig)
+			require.NoError(t, err)
+
+			stakeholderServ := httptest.NewServer(handler)
+
+			consortiumFile, err := mockmodels.DummyConsortiumJSON("foo.bar", []models.StakeholderListElement{
+				{
+					Domain:    stakeholderServ.URL,
+					DID:       tc.did,
+					PublicKey: pubKey,
+				},
+			})
+			require.NoError(t, err)
+
+			consortiumServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, consortiumFile)
+			}))
+
+			s := NewService(httpconfig.NewService(httpconfig.WithTLSConfig(&tls.Config{})))
+			_, err = s.GetEndpoints(consortiumServer.URL)
+			require.Error(t, err, tc.name)
+			require.Contains(t, err.Error(), tc.expectedErr, tc.name)
+
+			consortiumServer.Close()
+			stakeholderServ.Close()
+		}
+	})
 }
@@ -7,17 +7,20 @@ SPDX-License-Identifier: Apache-2.0
 package endpoint
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/trustbloc/trustbloc-did-method/pkg/vdri/trustbloc/models"
 )
 
 type discovery interface {
-	GetEndpoints(domain string) ([]*models.Endpoint, error)
+	GetEndpointsWithContext(ctx context.Context, domain string) ([]*models.Endpoint, error)
 }
 
 type selection interface {
-	SelectEndpoints(domain string, endpoints []*models.Endpoint) ([]*models.Endpoint, error)
+	SelectEndpointsWithContext(ctx context.Context, domain string,
+		endpoints []*models.Endpoint) ([]*models.Endpoint, error)
+	SelectQuorumWithContext(ctx context.Context, domain string, endpoints []*models.Endpoint) (*models.Quorum, error)
 }
 
 // EndpointService uses discovery service and selection service to fetch and filter endpoints
@@ -38,12 +41,40 @@ func NewService(d discovery, s selection) *EndpointService {
 
 // GetEndpoints get a list of endpoints to use from a consortium at a given domain
 func (es *EndpointService) GetEndpoints(domain string) ([]*models.Endpoint, error) {
-	eps, err := es.discovery.GetEndpoints(domain)
+	return es.GetEndpointsWithContext(context.Background(), domain)
+}
+
+// GetEndpointsWithContext get a list of endpoints to use from a consortium at a given domain,
+// the discovery and selection requests are cancelled with the context
+func (es *EndpointService) GetEndpointsWithContext(ctx context.Context, domain string) ([]*models.Endpoint, error) {
+	eps, err := es.discovery.GetEndpointsWithContext(ctx, domain)
+	if err != nil {
+		return nil, fmt.Errorf("discovery: %w", err)
+	}
+
+	out, err := es.selection.SelectEndpointsWithContext(ctx, domain, eps)
+	if err != nil {
+		return nil, fmt.Errorf("selection: %w", err)
+	}
+
+	return out, nil
+}
+
+// GetQuorum get an endpoint for each stakeholder of a consortium at a given domain, in the order they
+// should be queried, and the number of stakeholders that must agree on a resolution result
+func (es *EndpointService) GetQuorum(domain string) (*models.Quorum, error) {
+	return es.GetQuorumWithContext(context.Background(), domain)
+}
+
+// GetQuorumWithContext get an endpoint for each stakeholder of a consortium at a given domain and the number
+// of stakeholders that must agree, the discovery and selection requests are cancelled with the context
+func (es *EndpointService) GetQuorumWithContext(ctx context.Context, domain string) (*models.Quorum, error) {
+	eps, err := es.discovery.GetEndpointsWithContext(ctx, domain)
 	if err != nil {
 		return nil, fmt.Errorf("discovery: %w", err)
 	}
 
-	out, err := es.selection.SelectEndpoints(domain, eps)
+	out, err := es.selection.SelectQuorumWithContext(ctx, domain, eps)
 	if err != nil {
 		return nil, fmt.Errorf("selection: %w", err)
 	}
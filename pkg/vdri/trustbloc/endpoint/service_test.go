@@ -27,30 +27,48 @@ import (
 
 func TestEndpointService_GetEndpoints(t *testing.T) {
 	t.Run("success: get endpoints using static services", func(t *testing.T) {
-		shFile1, err := mockmodels.DummyStakeholderJSON("bar.baz", []string{
-			"https://bar.baz/webapi/123456", "https://bar.baz/webapi/654321"})
+		privKey1, pubKey1, err := mockmodels.GenerateKey("key1")
 		require.NoError(t, err)
 
-		stakeholderServ1 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			fmt.Fprint(w, shFile1)
-		}))
+		shFile1, err := mockmodels.SignStakeholder(mockmodels.DummyStakeholder("bar.baz", []string{
+			"https://bar.baz/webapi/123456", "https://bar.baz/webapi/654321"}), privKey1)
+		require.NoError(t, err)
+
+		didConfig1, err := mockmodels.DIDConfiguration("did:trustbloc:foo.bar:1", "127.0.0.1", privKey1)
+		require.NoError(t, err)
+
+		handler1, err := mockmodels.StakeholderHandler(shFile1, didConfig1)
+		require.NoError(t, err)
+
+		stakeholderServ1 := httptest.NewServer(handler1)
 		defer stakeholderServ1.Close()
 
-		shFile2, err := mockmodels.DummyStakeholderJSON("baz.qux", []string{
-			"https://baz.qux/iyoubhlkn/", "https://baz.foo/ukjhjtfyw/"})
+		privKey2, pubKey2, err := mockmodels.GenerateKey("key2")
 		require.NoError(t, err)
 
-		stakeholderServ2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			fmt.Fprint(w, shFile2)
-		}))
+		shFile2, err := mockmodels.SignStakeholder(mockmodels.DummyStakeholder("baz.qux", []string{
+			"https://baz.qux/iyoubhlkn/", "https://baz.foo/ukjhjtfyw/"}), privKey2)
+		require.NoError(t, err)
+
+		didConfig2, err := mockmodels.DIDConfiguration("did:trustbloc:foo.bar:2", "127.0.0.1", privKey2)
+		require.NoError(t, err)
+
+		handler2, err := mockmodels.StakeholderHandler(shFile2, didConfig2)
+		require.NoError(t, err)
+
+		stakeholderServ2 := httptest.NewServer(handler2)
 		defer stakeholderServ2.Close()
 
 		consortiumFile, err := mockmodels.DummyConsortiumJSON("foo.bar", []models.StakeholderListElement{
 			{
-				Domain: stakeholderServ1.URL,
+				Domain:    stakeholderServ1.URL,
+				DID:       "did:trustbloc:foo.bar:1",
+				PublicKey: pubKey1,
 			},
 			{
-				Domain: stakeholderServ2.URL,
+				Domain:    stakeholderServ2.URL,
+				DID:       "did:trustbloc:foo.bar:2",
+				PublicKey: pubKey2,
 			},
 		})
 		require.NoError(t, err)
@@ -114,3 +132,46 @@ func TestEndpointService_GetEndpoints(t *testing.T) {
 		require.Contains(t, err.Error(), "selection error")
 	})
 }
+
+func TestEndpointService_GetQuorum(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		endpointService := NewService(&mockdiscovery.MockDiscoveryService{
+			GetEndpointsFunc: func(domain string) ([]*models.Endpoint, error) {
+				return []*models.Endpoint{{URL: "url.1", Domain: "1"}, {URL: "url.2", Domain: "2"}}, nil
+			},
+		}, &mockselection.MockSelectionService{
+			SelectQuorumFunc: func(domain string, endpoints []*models.Endpoint) (*models.Quorum, error) {
+				return &models.Quorum{Endpoints: endpoints, Required: 1}, nil
+			}})
+
+		quorum, err := endpointService.GetQuorum("")
+		require.NoError(t, err)
+		require.Len(t, quorum.Endpoints, 2)
+		require.Equal(t, 1, quorum.Required)
+	})
+
+	t.Run("failure: discovery error", func(t *testing.T) {
+		endpointService := NewService(&mockdiscovery.MockDiscoveryService{
+			GetEndpointsFunc: func(domain string) ([]*models.Endpoint, error) {
+				return nil, fmt.Errorf("discovery error")
+			},
+		}, &mockselection.MockSelectionService{})
+
+		quorum, err := endpointService.GetQuorum("")
+		require.Error(t, err)
+		require.Nil(t, quorum)
+		require.Contains(t, err.Error(), "discovery error")
+	})
+
+	t.Run("failure: selection", func(t *testing.T) {
+		endpointService := NewService(&mockdiscovery.MockDiscoveryService{}, &mockselection.MockSelectionService{
+			SelectQuorumFunc: func(domain string, endpoints []*models.Endpoint) (*models.Quorum, error) {
+				return nil, fmt.Errorf("selection error")
+			}})
+
+		quorum, err := endpointService.GetQuorum("")
+		require.Error(t, err)
+		require.Nil(t, quorum)
+		require.Contains(t, err.Error(), "selection error")
+	})
+}
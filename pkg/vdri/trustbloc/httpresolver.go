@@ -0,0 +1,137 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package trustbloc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	docdid "github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+	log "github.com/sirupsen/logrus"
+
+	didclient "github.com/trustbloc/trustbloc-did-method/pkg/did"
+)
+
+const didLDJson = "application/did+ld+json"
+
+// httpResolver resolves dids from a sidetree node or resolver with the http binding,
+// the requests are cancelled with the context
+type httpResolver struct {
+	ctx         context.Context
+	endpointURL *url.URL
+	client      *http.Client
+	authToken   string
+}
+
+func newHTTPResolver(ctx context.Context, endpointURL string, client *http.Client,
+	authToken string) (*httpResolver, error) {
+	parsedURL, err := url.ParseRequestURI(endpointURL)
+	if err != nil {
+		return nil, fmt.Errorf("base URL invalid: %w", err)
+	}
+
+	return &httpResolver{ctx: ctx, endpointURL: parsedURL, client: client, authToken: authToken}, nil
+}
+
+// Read resolves the did document
+func (r *httpResolver) Read(did string, _ ...vdriapi.ResolveOpts) (*docdid.Doc, error) {
+	reqURL := *r.endpointURL
+	reqURL.Path = path.Join(reqURL.Path, did)
+
+	data, err := r.resolveDID(reqURL.String())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) == 0 {
+		return nil, vdriapi.ErrNotFound
+	}
+
+	var resolution struct {
+		DIDDocument json.RawMessage `json:"didDocument"`
+	}
+
+	if err := json.Unmarshal(data, &resolution); err != nil {
+		return nil, fmt.Errorf("unmarshal data return from http binding resolver %w", err)
+	}
+
+	didDocBytes := data
+	// check if data is did resolution
+	if len(resolution.DIDDocument) != 0 {
+		didDocBytes = resolution.DIDDocument
+	}
+
+	return didclient.ParseDocument(didDocBytes)
+}
+
+func (r *httpResolver) resolveDID(uri string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(r.ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP create get request failed: %w", err)
+	}
+
+	req.Header.Add("Accept", didLDJson)
+
+	if r.authToken != "" {
+		req.Header.Add("Authorization", r.authToken)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP Get request failed: %w", err)
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Errorf("Failed to close response body: %v", err)
+		}
+	}()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body failed: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusOK && strings.Contains(resp.Header.Get("Content-type"), didLDJson) {
+		return body, nil
+	}
+
+	return nil, &statusError{uri: uri, statusCode: resp.StatusCode, contentType: resp.Header.Get("Content-type"),
+		body: body}
+}
+
+// statusError is the error for a response of the http binding resolver other than a did document,
+// the sidetree node responds with 404 for an unknown DID and 410 for a deactivated DID
+type statusError struct {
+	uri         string
+	statusCode  int
+	contentType string
+	body        []byte
+}
+
+func (e *statusError) Error() string {
+	if e.statusCode == http.StatusNotFound {
+		return fmt.Sprintf("DID does not exist for request: %s", e.uri)
+	}
+
+	return fmt.Sprintf("unsupported response from DID resolver [%v] header [%s] body [%s]",
+		e.statusCode, e.contentType, e.body)
+}
+
+// hasStatus reports whether the error is a response of the http binding resolver with the status code
+func hasStatus(err error, statusCode int) bool {
+	var statusErr *statusError
+
+	return errors.As(err, &statusErr) && statusErr.statusCode == statusCode
+}
@@ -0,0 +1,79 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package trustbloc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	docdid "github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/protocol"
+	"github.com/trustbloc/sidetree-core-go/pkg/composer"
+	"github.com/trustbloc/sidetree-core-go/pkg/dochandler/didvalidator"
+	"github.com/trustbloc/sidetree-core-go/pkg/document"
+	"github.com/trustbloc/sidetree-core-go/pkg/operation"
+	"github.com/trustbloc/sidetree-core-go/pkg/restapi/model"
+
+	didclient "github.com/trustbloc/trustbloc-did-method/pkg/did"
+)
+
+const sha2_256 = 18
+
+// docFromInitialState builds the did document of a did that is not anchored yet from its initial state,
+// the same way a sidetree node does
+func docFromInitialState(shortFormDID, initialState string) (*docdid.Doc, error) {
+	const initialStateParts = 2
+
+	parts := strings.Split(initialState, ".")
+	if len(parts) != initialStateParts {
+		return nil, errors.New("initial state should have two parts: suffix data and delta")
+	}
+
+	createRequest, err := json.Marshal(&model.CreateRequest{
+		Operation:  model.OperationTypeCreate,
+		SuffixData: parts[0],
+		Delta:      parts[1],
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal initial state: %w", err)
+	}
+
+	op, err := operation.ParseCreateOperation(createRequest, protocol.Protocol{HashAlgorithmInMultiHashCode: sha2_256})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse initial state: %w", err)
+	}
+
+	if !strings.HasSuffix(shortFormDID, ":"+op.UniqueSuffix) {
+		return nil, fmt.Errorf("did %s doesn't match the did created from initial state", shortFormDID)
+	}
+
+	internal, err := composer.ApplyPatches(make(document.Document), op.Delta.Patches)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply initial state patches: %w", err)
+	}
+
+	return transformDocument(shortFormDID, internal)
+}
+
+// transformDocument returns the did document for the internal sidetree document of a did,
+// the same way a sidetree node does
+func transformDocument(did string, internal document.Document) (*docdid.Doc, error) {
+	internal[document.IDProperty] = did
+
+	result, err := didvalidator.New(nil).TransformDocument(internal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transform document: %w", err)
+	}
+
+	docBytes, err := result.Document.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal document: %w", err)
+	}
+
+	return didclient.ParseDocument(docBytes)
+}
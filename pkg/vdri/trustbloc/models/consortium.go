@@ -9,10 +9,14 @@ package models
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 
 	"github.com/square/go-jose"
+	"github.com/trustbloc/sidetree-core-go/pkg/docutil"
 )
 
+const sha2_256 = 18
+
 /*
 A consortium config file is a JWS, signed by the stakeholders,
 with the payload being a JSON object containing:
@@ -21,6 +25,7 @@ with the payload being a JSON object containing:
   - A list of stakeholders - containing, for each stakeholder:
     - The web domain where its configuration can be found
     - The did:trustbloc DID of the stakeholder
+    - The public key verifying the stakeholder's config file
   - The hash of the previous version of this config file
 */
 
@@ -48,18 +53,30 @@ type CacheControl struct {
 	MaxAge uint32 `json:"max_age"`
 }
 
-// StakeholderListElement holds the domain and DID of a stakeholder within the consortium
+// StakeholderListElement holds the domain, DID and verification key of a stakeholder within the consortium
 type StakeholderListElement struct {
 	// Domain is the domain name of the stakeholder
 	Domain string `json:"domain,omitempty"`
 	// DID is the DID of the stakeholder
 	DID string `json:"did,omitempty"`
+	// PublicKey is the verification key of the stakeholder, which its config file is signed with
+	PublicKey *PublicKey `json:"public_key,omitempty"`
+}
+
+// PublicKey holds the verification key of a stakeholder
+type PublicKey struct {
+	// ID is the DID URL of the key, the kid of the stakeholder's signatures
+	ID string `json:"id"`
+	// JWK is the public key in JWK format
+	JWK *jose.JSONWebKey `json:"jwk"`
 }
 
 // ConsortiumFileData holds the data within a consortium config file
 type ConsortiumFileData struct {
 	Config *Consortium
 	JWS    *jose.JSONWebSignature
+	// Hash is the encoded sha2-256 multihash of the consortium config payload
+	Hash string
 }
 
 // ParseConsortium parses the contents of a consortium file into a ConsortiumFileData object
@@ -78,8 +95,39 @@ func ParseConsortium(data []byte) (*ConsortiumFileData, error) {
 		return nil, err
 	}
 
+	hash, err := docutil.ComputeMultihash(sha2_256, configBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash consortium config: %w", err)
+	}
+
 	return &ConsortiumFileData{
 		Config: &config,
 		JWS:    jws,
+		Hash:   docutil.EncodeToString(hash),
 	}, nil
 }
+
+// VerifyEndorsements verifies the stakeholder signatures of the consortium config file against the public keys
+// of its members. At least num_queries distinct members must have signed it, all of them if num_queries is zero.
+// Configs whose members share a public key are rejected.
+func (c *ConsortiumFileData) VerifyEndorsements() error {
+	required := c.Config.Policy.NumQueries
+	if required == 0 {
+		required = len(c.Config.Members)
+	}
+
+	if required == 0 {
+		return fmt.Errorf("%w: the consortium has no members", ErrInsufficientEndorsement)
+	}
+
+	if err := verifyDistinctKeys(c.Config.Members); err != nil {
+		return err
+	}
+
+	if endorsed := countEndorsements(c.JWS, c.Config.Members); endorsed < required {
+		return fmt.Errorf("%w: %d of %d required stakeholder signatures verified", ErrInsufficientEndorsement,
+			endorsed, required)
+	}
+
+	return nil
+}
@@ -7,8 +7,10 @@ SPDX-License-Identifier: Apache-2.0
 package models_test
 
 import (
+	"errors"
 	"testing"
 
+	"github.com/square/go-jose"
 	"github.com/stretchr/testify/require"
 
 	mockmodels "github.com/trustbloc/trustbloc-did-method/pkg/internal/mock/models"
@@ -45,6 +47,11 @@ func Test_ParseConsortium(t *testing.T) {
 
 		require.Equal(t, "foo.bar", cData.Config.Domain)
 		require.Equal(t, payload, string(cData.JWS.UnsafePayloadWithoutVerification()))
+		require.NotEmpty(t, cData.Hash)
+
+		other, err := ParseConsortium([]byte(mockmodels.DummyJWSWrap(payload)))
+		require.NoError(t, err)
+		require.Equal(t, cData.Hash, other.Hash)
 	})
 
 	t.Run("failure: not valid JSON", func(t *testing.T) {
@@ -71,3 +78,118 @@ func Test_ParseConsortium(t *testing.T) {
 		require.Contains(t, err.Error(), "invalid character")
 	})
 }
+
+func TestConsortiumFileData_VerifyEndorsements(t *testing.T) {
+	privKey1, pubKey1, err := mockmodels.GenerateKey("key1")
+	require.NoError(t, err)
+
+	privKey2, pubKey2, err := mockmodels.GenerateKey("key2")
+	require.NoError(t, err)
+
+	privKey3, _, err := mockmodels.GenerateKey("key3")
+	require.NoError(t, err)
+
+	consortium := mockmodels.DummyConsortium("foo.bar", []StakeholderListElement{
+		{Domain: "bar.baz", PublicKey: pubKey1},
+		{Domain: "baz.qux", PublicKey: pubKey2},
+		{Domain: "qux.quux"},
+	})
+
+	parse := func(t *testing.T, numQueries int, keys ...*jose.JSONWebKey) *ConsortiumFileData {
+		consortium.Policy.NumQueries = numQueries
+
+		jws, err := mockmodels.SignConsortium(consortium, keys...)
+		require.NoError(t, err)
+
+		data, err := ParseConsortium([]byte(jws))
+		require.NoError(t, err)
+
+		return data
+	}
+
+	t.Run("success", func(t *testing.T) {
+		require.NoError(t, parse(t, 2, privKey1, privKey2).VerifyEndorsements())
+		require.NoError(t, parse(t, 1, privKey2).VerifyEndorsements())
+	})
+
+	t.Run("failure: not enough members signed", func(t *testing.T) {
+		err := parse(t, 2, privKey1).VerifyEndorsements()
+		require.True(t, errors.Is(err, ErrInsufficientEndorsement))
+		require.Contains(t, err.Error(), "1 of 2 required stakeholder signatures verified")
+	})
+
+	t.Run("failure: all members must sign by default", func(t *testing.T) {
+		err := parse(t, 0, privKey1, privKey2).VerifyEndorsements()
+		require.True(t, errors.Is(err, ErrInsufficientEndorsement))
+		require.Contains(t, err.Error(), "2 of 3 required stakeholder signatures verified")
+	})
+
+	t.Run("failure: signatures of the same member are counted once", func(t *testing.T) {
+		err := parse(t, 2, privKey1, privKey1).VerifyEndorsements()
+		require.True(t, errors.Is(err, ErrInsufficientEndorsement))
+		require.Contains(t, err.Error(), "1 of 2 required stakeholder signatures verified")
+	})
+
+	t.Run("failure: members sharing a key", func(t *testing.T) {
+		shared := mockmodels.DummyConsortium("foo.bar", []StakeholderListElement{
+			{Domain: "bar.baz", PublicKey: pubKey1},
+			{Domain: "baz.qux", PublicKey: pubKey1},
+		})
+
+		jws, err := mockmodels.SignConsortium(shared, privKey1, privKey1)
+		require.NoError(t, err)
+
+		data, err := ParseConsortium([]byte(jws))
+		require.NoError(t, err)
+
+		err = data.VerifyEndorsements()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "stakeholders bar.baz and baz.qux share the same public key")
+
+		// the same key listed under another id
+		shared.Members[1].PublicKey = &PublicKey{ID: "key4", JWK: pubKey1.JWK}
+
+		jws, err = mockmodels.SignConsortium(shared, privKey1,
+			&jose.JSONWebKey{Key: privKey1.Key, KeyID: "key4", Algorithm: privKey1.Algorithm})
+		require.NoError(t, err)
+
+		data, err = ParseConsortium([]byte(jws))
+		require.NoError(t, err)
+
+		err = data.VerifyEndorsements()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "share the same public key")
+	})
+
+	t.Run("failure: signature of a non member", func(t *testing.T) {
+		err := parse(t, 2, privKey1, privKey3).VerifyEndorsements()
+		require.True(t, errors.Is(err, ErrInsufficientEndorsement))
+		require.Contains(t, err.Error(), "1 of 2 required stakeholder signatures verified")
+	})
+
+	t.Run("failure: signature with the kid of another member", func(t *testing.T) {
+		forged := &jose.JSONWebKey{Key: privKey3.Key, KeyID: "key2", Algorithm: privKey3.Algorithm}
+
+		err := parse(t, 2, privKey1, forged).VerifyEndorsements()
+		require.True(t, errors.Is(err, ErrInsufficientEndorsement))
+		require.Contains(t, err.Error(), "1 of 2 required stakeholder signatures verified")
+	})
+
+	t.Run("failure: unsigned", func(t *testing.T) {
+		data, err := ParseConsortium([]byte(mockmodels.DummyJWSWrap(payload)))
+		require.NoError(t, err)
+
+		err = data.VerifyEndorsements()
+		require.True(t, errors.Is(err, ErrInsufficientEndorsement))
+		require.Contains(t, err.Error(), "0 of 2 required stakeholder signatures verified")
+	})
+
+	t.Run("failure: no members", func(t *testing.T) {
+		data, err := ParseConsortium([]byte(mockmodels.DummyJWSWrap(`{"domain":"foo.bar"}`)))
+		require.NoError(t, err)
+
+		err = data.VerifyEndorsements()
+		require.True(t, errors.Is(err, ErrInsufficientEndorsement))
+		require.Contains(t, err.Error(), "the consortium has no members")
+	})
+}
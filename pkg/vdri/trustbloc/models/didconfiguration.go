@@ -0,0 +1,135 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/square/go-jose"
+)
+
+/*
+A DID configuration is the well-known resource a stakeholder exposes under its domain,
+asserting the linkage between its DID and the domain. Each domain linkage assertion contains:
+  - The DID linked to the domain
+  - A JWT signed with a key of the DID, with the DID as issuer and the domain as claims
+*/
+
+// DIDConfiguration holds the domain linkage assertions of a well-known DID configuration resource
+type DIDConfiguration struct {
+	Entries []DomainLinkageAssertion `json:"entries"`
+}
+
+// DomainLinkageAssertion holds the JWT linking a DID to a domain
+type DomainLinkageAssertion struct {
+	// DID is the DID linked to the domain
+	DID string `json:"did"`
+	// JWT is the compact serialized JWT signed by the DID, with the linkage claims
+	JWT string `json:"jwt"`
+}
+
+// DomainLinkageClaims holds the claims of a domain linkage JWT
+type DomainLinkageClaims struct {
+	// Issuer is the DID linked to the domain
+	Issuer string `json:"iss"`
+	// Domain is the domain linked to the DID
+	Domain string `json:"domain"`
+}
+
+// ParseDIDConfiguration parses the contents of a DID configuration resource
+func ParseDIDConfiguration(data []byte) (*DIDConfiguration, error) {
+	var config DIDConfiguration
+
+	err := json.Unmarshal(data, &config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse did configuration: %w", err)
+	}
+
+	return &config, nil
+}
+
+// VerifyDomainLinkage verifies that the DID configuration links the DID to the domain: the JWT of an assertion
+// for the DID must be signed with the DID key, with the DID as issuer and the domain as domain claims
+func (c *DIDConfiguration) VerifyDomainLinkage(did, domain string, key *PublicKey) error {
+	var errs []string
+
+	for _, entry := range c.Entries {
+		if entry.DID != did {
+			continue
+		}
+
+		err := verifyDomainLinkage(entry.JWT, did, domain, key)
+		if err == nil {
+			return nil
+		}
+
+		errs = append(errs, err.Error())
+	}
+
+	if len(errs) == 0 {
+		return fmt.Errorf("no domain linkage assertion for did %s", did)
+	}
+
+	return fmt.Errorf("invalid domain linkage assertion for did %s: %s", did, strings.Join(errs, "; "))
+}
+
+// VerifyMemberLinkage verifies that the DID configuration links the DID of a consortium member to the host name
+// of its domain, with an assertion signed by the public key the consortium lists for the member
+func (c *DIDConfiguration) VerifyMemberLinkage(member *StakeholderListElement) error {
+	if member.DID == "" {
+		return fmt.Errorf("the consortium lists no did for stakeholder %s", member.Domain)
+	}
+
+	return c.VerifyDomainLinkage(member.DID, hostname(member.Domain), member.PublicKey)
+}
+
+// hostname returns the host name of a stakeholder domain, which may have a scheme and a port
+func hostname(domain string) string {
+	if !strings.Contains(domain, "://") {
+		domain = "https://" + domain
+	}
+
+	u, err := url.Parse(domain)
+	if err != nil {
+		return domain
+	}
+
+	return u.Hostname()
+}
+
+func verifyDomainLinkage(jwt, did, domain string, key *PublicKey) error {
+	jws, err := jose.ParseSigned(jwt)
+	if err != nil {
+		return errors.New("domain linkage assertion should be a JWT")
+	}
+
+	err = verifySignature(jws, key)
+	if err != nil {
+		return err
+	}
+
+	var claims DomainLinkageClaims
+
+	err = json.Unmarshal(jws.UnsafePayloadWithoutVerification(), &claims)
+	if err != nil {
+		return err
+	}
+
+	if claims.Issuer != did {
+		return fmt.Errorf("issuer '%s' doesn't match '%s'", claims.Issuer, did)
+	}
+
+	if claims.Domain != domain {
+		return fmt.Errorf("domain '%s' doesn't match '%s'", claims.Domain, domain)
+	}
+
+	return nil
+}
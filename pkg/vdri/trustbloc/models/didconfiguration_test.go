@@ -0,0 +1,143 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package models_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	mockmodels "github.com/trustbloc/trustbloc-did-method/pkg/internal/mock/models"
+	. "github.com/trustbloc/trustbloc-did-method/pkg/vdri/trustbloc/models"
+)
+
+const (
+	stakeholderDID    = "did:trustbloc:foo.bar:zQ1234567890987654321"
+	stakeholderDomain = "bar.baz"
+)
+
+func Test_ParseDIDConfiguration(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		config, err := ParseDIDConfiguration([]byte(`{"entries":[{"did":"` + stakeholderDID + `","jwt":"a.b.c"}]}`))
+		require.NoError(t, err)
+		require.Equal(t, []DomainLinkageAssertion{{DID: stakeholderDID, JWT: "a.b.c"}}, config.Entries)
+	})
+
+	t.Run("failure: malformed did configuration", func(t *testing.T) {
+		_, err := ParseDIDConfiguration([]byte(`{"entries":`))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to parse did configuration")
+	})
+}
+
+func TestDIDConfiguration_VerifyDomainLinkage(t *testing.T) {
+	privKey, pubKey, err := mockmodels.GenerateKey(stakeholderDID + "#key1")
+	require.NoError(t, err)
+
+	config, err := mockmodels.DIDConfiguration(stakeholderDID, stakeholderDomain, privKey)
+	require.NoError(t, err)
+
+	t.Run("success", func(t *testing.T) {
+		require.NoError(t, config.VerifyDomainLinkage(stakeholderDID, stakeholderDomain, pubKey))
+	})
+
+	t.Run("success: one of the assertions for the did is valid", func(t *testing.T) {
+		other, err := mockmodels.DIDConfiguration(stakeholderDID, "other.domain", privKey)
+		require.NoError(t, err)
+
+		both := &DIDConfiguration{Entries: append(other.Entries, config.Entries...)}
+
+		require.NoError(t, both.VerifyDomainLinkage(stakeholderDID, stakeholderDomain, pubKey))
+	})
+
+	t.Run("failure: no assertion for the did", func(t *testing.T) {
+		err := config.VerifyDomainLinkage("did:trustbloc:foo.bar:other", stakeholderDomain, pubKey)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "no domain linkage assertion for did did:trustbloc:foo.bar:other")
+	})
+
+	t.Run("failure: linked to another domain", func(t *testing.T) {
+		err := config.VerifyDomainLinkage(stakeholderDID, "other.domain", pubKey)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "domain 'bar.baz' doesn't match 'other.domain'")
+	})
+
+	t.Run("failure: issued by another did", func(t *testing.T) {
+		other, err := mockmodels.DIDConfiguration("did:trustbloc:foo.bar:other", stakeholderDomain, privKey)
+		require.NoError(t, err)
+
+		other.Entries[0].DID = stakeholderDID
+
+		err = other.VerifyDomainLinkage(stakeholderDID, stakeholderDomain, pubKey)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "issuer 'did:trustbloc:foo.bar:other' doesn't match")
+	})
+
+	t.Run("failure: signed with another key", func(t *testing.T) {
+		_, otherKey, err := mockmodels.GenerateKey(stakeholderDID + "#key1")
+		require.NoError(t, err)
+
+		err = config.VerifyDomainLinkage(stakeholderDID, stakeholderDomain, otherKey)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), ErrInvalidSignature.Error())
+
+		err = config.VerifyDomainLinkage(stakeholderDID, stakeholderDomain, &PublicKey{ID: stakeholderDID + "#key2",
+			JWK: pubKey.JWK})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "key id 'did:trustbloc:foo.bar:zQ1234567890987654321#key1' doesn't match")
+	})
+
+	t.Run("failure: not a JWT", func(t *testing.T) {
+		notJWT := &DIDConfiguration{Entries: []DomainLinkageAssertion{{DID: stakeholderDID, JWT: "not a jwt"}}}
+
+		err := notJWT.VerifyDomainLinkage(stakeholderDID, stakeholderDomain, pubKey)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "domain linkage assertion should be a JWT")
+	})
+
+	t.Run("failure: malformed claims", func(t *testing.T) {
+		jwt, err := mockmodels.SignedJWSWrap(`{"iss":`, privKey)
+		require.NoError(t, err)
+
+		malformed := &DIDConfiguration{Entries: []DomainLinkageAssertion{{DID: stakeholderDID, JWT: jwt}}}
+
+		err = malformed.VerifyDomainLinkage(stakeholderDID, stakeholderDomain, pubKey)
+		require.Error(t, err)
+		require.False(t, errors.Is(err, ErrInvalidSignature))
+		require.Contains(t, err.Error(), "unexpected end of JSON input")
+	})
+}
+
+func TestDIDConfiguration_VerifyMemberLinkage(t *testing.T) {
+	privKey, pubKey, err := mockmodels.GenerateKey(stakeholderDID + "#key1")
+	require.NoError(t, err)
+
+	config, err := mockmodels.DIDConfiguration(stakeholderDID, stakeholderDomain, privKey)
+	require.NoError(t, err)
+
+	t.Run("success: the domain may have a scheme and a port", func(t *testing.T) {
+		for _, domain := range []string{"bar.baz", "bar.baz:8088", "https://bar.baz:8088"} {
+			member := &StakeholderListElement{Domain: domain, DID: stakeholderDID, PublicKey: pubKey}
+			require.NoError(t, config.VerifyMemberLinkage(member), domain)
+		}
+	})
+
+	t.Run("failure: linked to another domain", func(t *testing.T) {
+		member := &StakeholderListElement{Domain: "http://127.0.0.1:8088", DID: stakeholderDID, PublicKey: pubKey}
+
+		err := config.VerifyMemberLinkage(member)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "domain 'bar.baz' doesn't match '127.0.0.1'")
+	})
+
+	t.Run("failure: no did listed for the member", func(t *testing.T) {
+		err := config.VerifyMemberLinkage(&StakeholderListElement{Domain: stakeholderDomain, PublicKey: pubKey})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "the consortium lists no did for stakeholder bar.baz")
+	})
+}
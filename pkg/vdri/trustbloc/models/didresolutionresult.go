@@ -9,6 +9,7 @@ package models
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
 )
@@ -19,24 +20,67 @@ const (
 
 // DIDResolutionResult holds the result of a DID resolution operation
 type DIDResolutionResult struct {
-	Context        string          `json:"@context"`
-	DIDDocument    json.RawMessage `json:"didDocument"`
-	MethodMetadata MethodMetaData  `json:"methodMetadata"`
+	Context          string           `json:"@context"`
+	DIDDocument      json.RawMessage  `json:"didDocument,omitempty"`
+	ResolverMetadata ResolverMetadata `json:"resolverMetadata"`
+	MethodMetadata   MethodMetaData   `json:"methodMetadata"`
 }
 
-// MethodMetaData dummy object
-type MethodMetaData struct{}
+// ResolverMetadata holds the metadata of a did resolution
+type ResolverMetadata struct {
+	// Retrieved is the time the resolution started
+	Retrieved time.Time `json:"retrieved"`
+	// Duration is the resolution time in milliseconds
+	Duration int64 `json:"duration"`
+	// Cached is true when the result was served from the resolution cache
+	Cached bool `json:"cached,omitempty"`
+}
 
-// MakeDIDResolutionResult constructs, marshals, and returns a DID resolution result containing only a DID document
-func MakeDIDResolutionResult(doc *did.Doc) ([]byte, error) {
-	docBytes, err := doc.JSONBytes()
-	if err != nil {
-		return nil, fmt.Errorf("marshalling did doc: %w", err)
-	}
+// MethodMetaData holds the trustbloc method metadata of a resolved did
+type MethodMetaData struct {
+	// Published is false when the document was built from the initial state of a long-form did not anchored yet
+	Published bool `json:"published"`
+	// Deactivated is true when the did has been deactivated, there is no document in that case
+	Deactivated bool `json:"deactivated,omitempty"`
+	// ConsortiumHash is the hash of the consortium config the endpoints were selected from
+	ConsortiumHash string `json:"consortiumHash,omitempty"`
+	// RequiredAgreement is the number of endpoints that had to return the same result
+	RequiredAgreement int `json:"requiredAgreement,omitempty"`
+	// Endpoints are the endpoints queried for the did
+	Endpoints []EndpointMetadata `json:"endpoints,omitempty"`
+}
+
+// EndpointMetadata holds the outcome of a did resolution from an endpoint
+type EndpointMetadata struct {
+	URL string `json:"url"`
+	// Stakeholder is the domain of the stakeholder serving the endpoint
+	Stakeholder string `json:"stakeholder,omitempty"`
+	// Responded is false when the result was obtained before the endpoint responded
+	Responded bool `json:"responded"`
+	// Agreed is true when the endpoint returned the result
+	Agreed bool   `json:"agreed"`
+	Error  string `json:"error,omitempty"`
+	// Duration is the endpoint response time in milliseconds
+	Duration int64 `json:"duration,omitempty"`
+}
 
+// MakeDIDResolutionResult constructs, marshals, and returns a DID resolution result.
+// The DID document is nil for deactivated DIDs.
+func MakeDIDResolutionResult(doc *did.Doc, resolverMetadata *ResolverMetadata,
+	methodMetadata *MethodMetaData) ([]byte, error) {
 	drr := &DIDResolutionResult{
-		Context:     didResolutionResultNamespace,
-		DIDDocument: docBytes,
+		Context:          didResolutionResultNamespace,
+		ResolverMetadata: *resolverMetadata,
+		MethodMetadata:   *methodMetadata,
+	}
+
+	if doc != nil {
+		docBytes, err := doc.JSONBytes()
+		if err != nil {
+			return nil, fmt.Errorf("marshalling did doc: %w", err)
+		}
+
+		drr.DIDDocument = docBytes
 	}
 
 	return json.Marshal(drr)
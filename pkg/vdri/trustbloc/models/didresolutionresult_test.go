@@ -18,15 +18,36 @@ import (
 func TestMakeDIDResolutionResult(t *testing.T) {
 	mockdoc := mockdiddoc.GetMockDIDDoc()
 
-	resultBytes, err := MakeDIDResolutionResult(mockdoc)
-	require.NoError(t, err)
-
-	var result DIDResolutionResult
-	err = json.Unmarshal(resultBytes, &result)
-	require.NoError(t, err)
-
-	docBytes, err := mockdoc.JSONBytes()
-	require.NoError(t, err)
-
-	require.True(t, bytes.Equal(docBytes, result.DIDDocument))
+	t.Run("test success", func(t *testing.T) {
+		resultBytes, err := MakeDIDResolutionResult(mockdoc, &ResolverMetadata{Duration: 12},
+			&MethodMetaData{Published: true, ConsortiumHash: "hash", RequiredAgreement: 1,
+				Endpoints: []EndpointMetadata{{URL: "url", Responded: true, Agreed: true}}})
+		require.NoError(t, err)
+
+		var result DIDResolutionResult
+		err = json.Unmarshal(resultBytes, &result)
+		require.NoError(t, err)
+
+		docBytes, err := mockdoc.JSONBytes()
+		require.NoError(t, err)
+
+		require.True(t, bytes.Equal(docBytes, result.DIDDocument))
+		require.Equal(t, int64(12), result.ResolverMetadata.Duration)
+		require.True(t, result.MethodMetadata.Published)
+		require.Equal(t, "hash", result.MethodMetadata.ConsortiumHash)
+		require.Equal(t, "url", result.MethodMetadata.Endpoints[0].URL)
+	})
+
+	t.Run("test deactivated", func(t *testing.T) {
+		resultBytes, err := MakeDIDResolutionResult(nil, &ResolverMetadata{},
+			&MethodMetaData{Deactivated: true})
+		require.NoError(t, err)
+
+		var result map[string]interface{}
+		err = json.Unmarshal(resultBytes, &result)
+		require.NoError(t, err)
+
+		require.NotContains(t, result, "didDocument")
+		require.Equal(t, true, result["methodMetadata"].(map[string]interface{})["deactivated"])
+	})
 }
@@ -10,3 +10,12 @@ type Endpoint struct {
 	URL    string
 	Domain string
 }
+
+// Quorum holds the endpoints to query for a consortium, one for each stakeholder in the order they should be
+// queried, and the number of them that must agree on a resolution result
+type Quorum struct {
+	Endpoints []*Endpoint
+	Required  int
+	// ConsortiumHash is the hash of the consortium config the endpoints were selected from
+	ConsortiumHash string
+}
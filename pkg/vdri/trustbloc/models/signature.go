@@ -0,0 +1,111 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package models
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+
+	"github.com/square/go-jose"
+)
+
+// ErrInvalidSignature is returned for a config file whose signature doesn't verify against the expected key
+var ErrInvalidSignature = errors.New("invalid config signature") //nolint:gochecknoglobals
+
+// ErrInsufficientEndorsement is returned for a consortium config file signed by too few of its stakeholders
+var ErrInsufficientEndorsement = errors.New( //nolint:gochecknoglobals
+	"insufficient stakeholder endorsement of consortium config file")
+
+// verifySignature verifies the single signature of a JWS against a public key. The kid of the protected
+// header must be the key id, and its algorithm must match the algorithm of the JWK if it has one.
+func verifySignature(jws *jose.JSONWebSignature, key *PublicKey) error {
+	if key == nil || key.JWK == nil || !key.JWK.Valid() || !key.JWK.IsPublic() {
+		return fmt.Errorf("%w: missing or invalid public key", ErrInvalidSignature)
+	}
+
+	if len(jws.Signatures) != 1 {
+		return fmt.Errorf("%w: expected one signature, got %d", ErrInvalidSignature, len(jws.Signatures))
+	}
+
+	header := jws.Signatures[0].Protected
+
+	if header.KeyID != key.ID {
+		return fmt.Errorf("%w: key id '%s' doesn't match '%s'", ErrInvalidSignature, header.KeyID, key.ID)
+	}
+
+	if key.JWK.Algorithm != "" && header.Algorithm != key.JWK.Algorithm {
+		return fmt.Errorf("%w: algorithm '%s' doesn't match '%s'", ErrInvalidSignature, header.Algorithm,
+			key.JWK.Algorithm)
+	}
+
+	if _, err := jws.Verify(key.JWK); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidSignature, err)
+	}
+
+	return nil
+}
+
+// countEndorsements returns the number of distinct member keys with a valid signature in the JWS,
+// keys are identified by their JWK thumbprint so that a key listed by several members is counted once
+func countEndorsements(jws *jose.JSONWebSignature, members []StakeholderListElement) int {
+	endorsed := make(map[string]bool)
+
+	for i := range jws.Signatures {
+		single := *jws
+		single.Signatures = jws.Signatures[i : i+1]
+
+		for j := range members {
+			if verifySignature(&single, members[j].PublicKey) != nil {
+				continue
+			}
+
+			thumbprint, err := keyThumbprint(members[j].PublicKey)
+			if err == nil {
+				endorsed[thumbprint] = true
+			}
+
+			break
+		}
+	}
+
+	return len(endorsed)
+}
+
+// verifyDistinctKeys checks that no two members list the same public key
+func verifyDistinctKeys(members []StakeholderListElement) error {
+	domains := make(map[string]string)
+
+	for i := range members {
+		if members[i].PublicKey == nil || members[i].PublicKey.JWK == nil {
+			continue
+		}
+
+		thumbprint, err := keyThumbprint(members[i].PublicKey)
+		if err != nil {
+			return fmt.Errorf("invalid public key of stakeholder %s: %w", members[i].Domain, err)
+		}
+
+		if domain, ok := domains[thumbprint]; ok {
+			return fmt.Errorf("stakeholders %s and %s share the same public key", domain, members[i].Domain)
+		}
+
+		domains[thumbprint] = members[i].Domain
+	}
+
+	return nil
+}
+
+// keyThumbprint returns the RFC 7638 sha-256 thumbprint of the JWK
+func keyThumbprint(key *PublicKey) (string, error) {
+	thumbprint, err := key.JWK.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return "", err
+	}
+
+	return string(thumbprint), nil
+}
@@ -70,3 +70,9 @@ func ParseStakeholder(data []byte) (*StakeholderFileData, error) {
 		JWS:    jws,
 	}, nil
 }
+
+// VerifySignature verifies the stakeholder config file against the stakeholder's public key,
+// as listed in the consortium config
+func (s *StakeholderFileData) VerifySignature(key *PublicKey) error {
+	return verifySignature(s.JWS, key)
+}
@@ -7,8 +7,10 @@ SPDX-License-Identifier: Apache-2.0
 package models_test
 
 import (
+	"errors"
 	"testing"
 
+	"github.com/square/go-jose"
 	"github.com/stretchr/testify/require"
 
 	mockmodels "github.com/trustbloc/trustbloc-did-method/pkg/internal/mock/models"
@@ -67,3 +69,83 @@ func Test_ParseStakeholder(t *testing.T) {
 		require.Contains(t, err.Error(), "unexpected end")
 	})
 }
+
+func TestStakeholderFileData_VerifySignature(t *testing.T) {
+	privKey, pubKey, err := mockmodels.GenerateKey("key1")
+	require.NoError(t, err)
+
+	signed, err := mockmodels.SignedJWSWrap(exampleStakeholders[0], privKey)
+	require.NoError(t, err)
+
+	stakeholder, err := ParseStakeholder([]byte(signed))
+	require.NoError(t, err)
+
+	t.Run("success", func(t *testing.T) {
+		require.NoError(t, stakeholder.VerifySignature(pubKey))
+	})
+
+	t.Run("failure: signed with another key", func(t *testing.T) {
+		_, otherKey, err := mockmodels.GenerateKey("key1")
+		require.NoError(t, err)
+
+		err = stakeholder.VerifySignature(otherKey)
+		require.True(t, errors.Is(err, ErrInvalidSignature))
+		require.Contains(t, err.Error(), "square/go-jose: error in cryptographic primitive")
+	})
+
+	t.Run("failure: unsigned", func(t *testing.T) {
+		unsigned, err := ParseStakeholder([]byte(mockmodels.DummyJWSWrap(exampleStakeholders[0])))
+		require.NoError(t, err)
+
+		err = unsigned.VerifySignature(&PublicKey{JWK: pubKey.JWK})
+		require.True(t, errors.Is(err, ErrInvalidSignature))
+	})
+
+	t.Run("failure: key id doesn't match", func(t *testing.T) {
+		err := stakeholder.VerifySignature(&PublicKey{ID: "key2", JWK: pubKey.JWK})
+		require.True(t, errors.Is(err, ErrInvalidSignature))
+		require.Contains(t, err.Error(), "key id 'key1' doesn't match 'key2'")
+	})
+
+	t.Run("failure: algorithm doesn't match", func(t *testing.T) {
+		err := stakeholder.VerifySignature(&PublicKey{ID: "key1",
+			JWK: &jose.JSONWebKey{Key: pubKey.JWK.Key, Algorithm: string(jose.ES256)}})
+		require.True(t, errors.Is(err, ErrInvalidSignature))
+		require.Contains(t, err.Error(), "algorithm 'EdDSA' doesn't match 'ES256'")
+	})
+
+	t.Run("failure: not a public key", func(t *testing.T) {
+		err := stakeholder.VerifySignature(nil)
+		require.True(t, errors.Is(err, ErrInvalidSignature))
+		require.Contains(t, err.Error(), "missing or invalid public key")
+
+		err = stakeholder.VerifySignature(&PublicKey{ID: "key1", JWK: privKey})
+		require.True(t, errors.Is(err, ErrInvalidSignature))
+		require.Contains(t, err.Error(), "missing or invalid public key")
+
+		err = stakeholder.VerifySignature(&PublicKey{ID: "key1", JWK: &jose.JSONWebKey{Key: []byte("secret")}})
+		require.True(t, errors.Is(err, ErrInvalidSignature))
+		require.Contains(t, err.Error(), "missing or invalid public key")
+	})
+
+	t.Run("failure: more than one signature", func(t *testing.T) {
+		otherPrivKey, _, err := mockmodels.GenerateKey("key2")
+		require.NoError(t, err)
+
+		signer, err := jose.NewMultiSigner([]jose.SigningKey{
+			{Algorithm: jose.EdDSA, Key: privKey},
+			{Algorithm: jose.EdDSA, Key: otherPrivKey},
+		}, nil)
+		require.NoError(t, err)
+
+		jws, err := signer.Sign([]byte(exampleStakeholders[0]))
+		require.NoError(t, err)
+
+		multiSigned, err := ParseStakeholder([]byte(jws.FullSerialize()))
+		require.NoError(t, err)
+
+		err = multiSigned.VerifySignature(pubKey)
+		require.True(t, errors.Is(err, ErrInvalidSignature))
+		require.Contains(t, err.Error(), "expected one signature, got 2")
+	})
+}
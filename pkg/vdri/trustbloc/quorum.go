@@ -0,0 +1,191 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package trustbloc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	docdid "github.com/hyperledger/aries-framework-go/pkg/doc/did"
+
+	"github.com/trustbloc/trustbloc-did-method/pkg/internal/common/jsoncanonicalizer"
+	"github.com/trustbloc/trustbloc-did-method/pkg/vdri/trustbloc/models"
+)
+
+const (
+	deactivatedOutcome = "deactivated"
+	notFoundOutcome    = "not found"
+)
+
+// InsufficientAgreementError is returned by Read when fewer stakeholders than required by the
+// consortium policy return the same did document
+type InsufficientAgreementError struct {
+	DID      string
+	Required int
+	Agreed   int
+	Failures []error
+}
+
+func (e *InsufficientAgreementError) Error() string {
+	msg := fmt.Sprintf("insufficient agreement for did %s: %d of %d required stakeholders agree",
+		e.DID, e.Agreed, e.Required)
+
+	if len(e.Failures) > 0 {
+		failures := make([]string, len(e.Failures))
+		for i, err := range e.Failures {
+			failures[i] = err.Error()
+		}
+
+		msg += fmt.Sprintf(", failures: [%s]", strings.Join(failures, "; "))
+	}
+
+	return msg
+}
+
+// endpointResult is the response of an endpoint to a did resolution
+type endpointResult struct {
+	endpointURL string
+	doc         *docdid.Doc
+	err         error
+	duration    time.Duration
+	// responded is false when the endpoint didn't respond in time
+	responded bool
+}
+
+// quorumResult is an outcome returned by one or more stakeholders: a did document or the deactivated
+// or not found error
+type quorumResult struct {
+	outcome string
+	doc     *docdid.Doc
+	err     error
+	count   int
+}
+
+// quorum tallies the outcomes of the stakeholders queried for a did. Stakeholders agree when they return
+// byte-identical canonical documents, or both report the did as deactivated or not found.
+type quorum struct {
+	did       string
+	endpoints []*models.Endpoint
+	required  int
+	remaining int
+	agreed    int
+	results   map[string]*quorumResult
+	responses map[string]*endpointResult
+	outcomes  map[string]string
+	failures  []error
+}
+
+func newQuorum(did string, required int, endpoints []*models.Endpoint) *quorum {
+	return &quorum{
+		did:       did,
+		endpoints: endpoints,
+		required:  required,
+		remaining: len(endpoints),
+		results:   make(map[string]*quorumResult),
+		responses: make(map[string]*endpointResult),
+		outcomes:  make(map[string]string),
+	}
+}
+
+// add records the outcome of a stakeholder. It returns the agreed result once quorum is reached,
+// or an InsufficientAgreementError once it can't be reached anymore.
+func (q *quorum) add(r *endpointResult) (*quorumResult, error) {
+	q.remaining--
+	q.responses[r.endpointURL] = r
+
+	outcome, err := q.outcome(r.doc, r.err)
+	if err != nil {
+		q.failures = append(q.failures, fmt.Errorf("endpoint %s: %w", r.endpointURL, err))
+	} else {
+		q.outcomes[r.endpointURL] = outcome
+
+		result, ok := q.results[outcome]
+		if !ok {
+			result = &quorumResult{outcome: outcome, doc: r.doc}
+			q.results[outcome] = result
+		}
+
+		result.count++
+
+		if result.count > q.agreed {
+			q.agreed = result.count
+		}
+
+		if result.count >= q.required {
+			return result, nil
+		}
+	}
+
+	if q.agreed+q.remaining < q.required {
+		return nil, &InsufficientAgreementError{DID: q.did, Required: q.required, Agreed: q.agreed,
+			Failures: q.failures}
+	}
+
+	return nil, nil
+}
+
+func (q *quorum) outcome(doc *docdid.Doc, err error) (string, error) {
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrDIDDeactivated):
+			q.recordError(deactivatedOutcome, err)
+
+			return deactivatedOutcome, nil
+		case isNotFound(err):
+			q.recordError(notFoundOutcome, err)
+
+			return notFoundOutcome, nil
+		default:
+			return "", err
+		}
+	}
+
+	docBytes, err := doc.JSONBytes()
+	if err != nil {
+		return "", fmt.Errorf("cannot marshal resolved doc: %w", err)
+	}
+
+	canonicalBytes, err := jsoncanonicalizer.Transform(docBytes)
+	if err != nil {
+		return "", fmt.Errorf("cannot canonicalize resolved doc: %w", err)
+	}
+
+	return string(canonicalBytes), nil
+}
+
+// recordError keeps the first error returned for an error outcome
+func (q *quorum) recordError(outcome string, err error) {
+	if _, ok := q.results[outcome]; !ok {
+		q.results[outcome] = &quorumResult{outcome: outcome, err: err}
+	}
+}
+
+// metadata returns the method metadata of the agreed result
+func (q *quorum) metadata(result *quorumResult, consortiumHash string) *models.MethodMetaData {
+	metadata := &models.MethodMetaData{ConsortiumHash: consortiumHash, RequiredAgreement: q.required}
+
+	for _, e := range q.endpoints {
+		endpoint := models.EndpointMetadata{URL: e.URL, Stakeholder: e.Domain}
+
+		if r, ok := q.responses[e.URL]; ok {
+			outcome, agreed := q.outcomes[e.URL]
+
+			endpoint.Responded = r.responded
+			endpoint.Agreed = agreed && outcome == result.outcome
+			endpoint.Duration = r.duration.Milliseconds()
+
+			if r.err != nil {
+				endpoint.Error = r.err.Error()
+			}
+		}
+
+		metadata.Endpoints = append(metadata.Endpoints, endpoint)
+	}
+
+	return metadata
+}
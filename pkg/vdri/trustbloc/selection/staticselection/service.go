@@ -6,15 +6,21 @@ SPDX-License-Identifier: Apache-2.0
 package staticselection
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 
 	"github.com/trustbloc/trustbloc-did-method/pkg/vdri/trustbloc/models"
 )
 
+// ErrInsufficientStakeholders is returned when fewer stakeholders are discovered than the consortium policy
+// requires to agree on a resolution result
+var ErrInsufficientStakeholders = errors.New("not enough stakeholders to reach the consortium quorum") //nolint:gochecknoglobals,lll
+
 type config interface {
-	GetConsortium(url, domain string) (*models.ConsortiumFileData, error)
-	GetStakeholder(url, domain string) (*models.StakeholderFileData, error)
+	GetConsortiumWithContext(ctx context.Context, url, domain string) (*models.ConsortiumFileData, error)
+	GetStakeholderWithContext(ctx context.Context, url, domain string) (*models.StakeholderFileData, error)
 }
 
 // SelectionService implements a static selection service
@@ -30,7 +36,32 @@ func NewService(config config) *SelectionService {
 // SelectEndpoints select a random endpoint for each of N random stakeholders in a consortium
 // Where N is the num-queries parameter in the consortium's policy configuration
 func (ds *SelectionService) SelectEndpoints(consortiumDomain string, endpoints []*models.Endpoint) ([]*models.Endpoint, error) { // nolint: lll
-	consortiumData, err := ds.config.GetConsortium(consortiumDomain, consortiumDomain)
+	return ds.SelectEndpointsWithContext(context.Background(), consortiumDomain, endpoints)
+}
+
+// SelectEndpointsWithContext select a random endpoint for each of N random stakeholders in a consortium,
+// the config request is cancelled with the context
+func (ds *SelectionService) SelectEndpointsWithContext(ctx context.Context, consortiumDomain string, endpoints []*models.Endpoint) ([]*models.Endpoint, error) { // nolint: lll
+	quorum, err := ds.SelectQuorumWithContext(ctx, consortiumDomain, endpoints)
+	if err != nil {
+		return nil, err
+	}
+
+	return quorum.Endpoints[:quorum.Required], nil
+}
+
+// SelectQuorum select a random endpoint for each stakeholder in a consortium, in random order,
+// and returns them with the number N of stakeholders that must agree on a resolution result
+// Where N is the num-queries parameter in the consortium's policy configuration
+func (ds *SelectionService) SelectQuorum(consortiumDomain string, endpoints []*models.Endpoint) (*models.Quorum, error) { // nolint: lll
+	return ds.SelectQuorumWithContext(context.Background(), consortiumDomain, endpoints)
+}
+
+// SelectQuorumWithContext select a random endpoint for each stakeholder in a consortium, in random order,
+// and the number of stakeholders that must agree, the config request is cancelled with the context.
+// ErrInsufficientStakeholders is returned if fewer stakeholders than N have endpoints.
+func (ds *SelectionService) SelectQuorumWithContext(ctx context.Context, consortiumDomain string, endpoints []*models.Endpoint) (*models.Quorum, error) { // nolint: lll
+	consortiumData, err := ds.config.GetConsortiumWithContext(ctx, consortiumDomain, consortiumDomain)
 	if err != nil {
 		return nil, fmt.Errorf("getting consortium: %w", err)
 	}
@@ -63,12 +94,14 @@ func (ds *SelectionService) SelectEndpoints(consortiumDomain string, endpoints [
 		n = len(d)
 	}
 
-	perm := rand.Perm(len(d))
+	if n > len(d) {
+		return nil, fmt.Errorf("%w: %d stakeholders required, %d discovered", ErrInsufficientStakeholders, n, len(d))
+	}
 
-	for i := 0; i < n && i < len(d); i++ {
-		list := domains[d[perm[i]]]
+	for _, i := range rand.Perm(len(d)) {
+		list := domains[d[i]]
 		out = append(out, list[rand.Intn(len(list))])
 	}
 
-	return out, nil
+	return &models.Quorum{Endpoints: out, Required: n, ConsortiumHash: consortiumData.Hash}, nil
 }
@@ -6,6 +6,7 @@ SPDX-License-Identifier: Apache-2.0
 package staticselection
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -99,3 +100,63 @@ func TestSelectionService_SelectEndpoints(t *testing.T) {
 		require.Equal(t, 2, intersectionSize(selectedEndpoints, endpoints))
 	})
 }
+
+func TestSelectionService_SelectQuorum(t *testing.T) {
+	endpoints := []*models.Endpoint{
+		{URL: "url.1", Domain: "1"},
+		{URL: "url.2", Domain: "1"},
+		{URL: "url.3", Domain: "2"},
+		{URL: "url.4", Domain: "3"},
+	}
+
+	t.Run("test success - M of N", func(t *testing.T) {
+		s := NewService(&mockconfig.MockConfigService{
+			GetConsortiumFunc: func(s string, s2 string) (*models.ConsortiumFileData, error) {
+				return &models.ConsortiumFileData{
+					Config: &models.Consortium{
+						Policy: models.ConsortiumPolicy{NumQueries: 2},
+					},
+					Hash: "hash",
+				}, nil
+			}})
+
+		quorum, err := s.SelectQuorum("domain", endpoints)
+		require.NoError(t, err)
+		require.Equal(t, 2, quorum.Required)
+		require.Equal(t, "hash", quorum.ConsortiumHash)
+		require.Len(t, quorum.Endpoints, 3)
+		require.Equal(t, 1, intersectionSize(quorum.Endpoints, endpoints[:2]))
+		require.Equal(t, 2, intersectionSize(quorum.Endpoints, endpoints[2:]))
+	})
+
+	t.Run("test error - num queries greater than stakeholders", func(t *testing.T) {
+		s := NewService(&mockconfig.MockConfigService{
+			GetConsortiumFunc: func(s string, s2 string) (*models.ConsortiumFileData, error) {
+				return &models.ConsortiumFileData{
+					Config: &models.Consortium{
+						Policy: models.ConsortiumPolicy{NumQueries: 5},
+					},
+				}, nil
+			}})
+
+		quorum, err := s.SelectQuorum("domain", endpoints)
+		require.Error(t, err)
+		require.True(t, errors.Is(err, ErrInsufficientStakeholders))
+		require.Contains(t, err.Error(), "5 stakeholders required, 3 discovered")
+		require.Nil(t, quorum)
+
+		_, err = s.SelectEndpoints("domain", endpoints)
+		require.True(t, errors.Is(err, ErrInsufficientStakeholders))
+	})
+
+	t.Run("test error from get consortium", func(t *testing.T) {
+		s := NewService(&mockconfig.MockConfigService{
+			GetConsortiumFunc: func(s string, s2 string) (*models.ConsortiumFileData, error) {
+				return nil, errors.New("consortium error")
+			}})
+
+		_, err := s.SelectQuorum("domain", endpoints)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "consortium error")
+	})
+}
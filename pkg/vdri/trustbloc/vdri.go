@@ -6,66 +6,136 @@ SPDX-License-Identifier: Apache-2.0
 package trustbloc
 
 import (
-	"bytes"
+	"context"
 	"crypto/tls"
 	"errors"
 	"fmt"
-	"strings"
+	"net/http"
+	"time"
 
 	docdid "github.com/hyperledger/aries-framework-go/pkg/doc/did"
 	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
-	"github.com/hyperledger/aries-framework-go/pkg/vdri/httpbinding"
-	log "github.com/sirupsen/logrus"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
 
-	"github.com/trustbloc/trustbloc-did-method/pkg/internal/common/jsoncanonicalizer"
+	didclient "github.com/trustbloc/trustbloc-did-method/pkg/did"
+	"github.com/trustbloc/trustbloc-did-method/pkg/internal/common/resilient"
 	"github.com/trustbloc/trustbloc-did-method/pkg/vdri/trustbloc/config/httpconfig"
 	"github.com/trustbloc/trustbloc-did-method/pkg/vdri/trustbloc/config/verifyingconfig"
+	"github.com/trustbloc/trustbloc-did-method/pkg/vdri/trustbloc/discovery/bootstrapdiscovery"
 	"github.com/trustbloc/trustbloc-did-method/pkg/vdri/trustbloc/discovery/staticdiscovery"
 	"github.com/trustbloc/trustbloc-did-method/pkg/vdri/trustbloc/endpoint"
 	"github.com/trustbloc/trustbloc-did-method/pkg/vdri/trustbloc/models"
 	"github.com/trustbloc/trustbloc-did-method/pkg/vdri/trustbloc/selection/staticselection"
 )
 
+const (
+	defaultTimeout  = 10 * time.Second
+	identifiersPath = "/identifiers"
+)
+
+// ErrDIDDeactivated is returned by Read when the DID has been deactivated
+var ErrDIDDeactivated = errors.New("did has been deactivated") //nolint:gochecknoglobals
+
+// ErrVersionNotSupported is returned by Read when a version of the DID document is requested,
+// the sidetree endpoints only resolve the latest version
+var ErrVersionNotSupported = errors.New("resolving a version of the did document is not supported") //nolint:gochecknoglobals,lll
+
 type endpointService interface {
-	GetEndpoints(domain string) ([]*models.Endpoint, error)
+	GetQuorumWithContext(ctx context.Context, domain string) (*models.Quorum, error)
+}
+
+type configService interface {
+	GetConsortiumWithContext(ctx context.Context, url, domain string) (*models.ConsortiumFileData, error)
+	GetStakeholderWithContext(ctx context.Context, url, domain string) (*models.StakeholderFileData, error)
+	GetDIDConfigurationWithContext(ctx context.Context, url string) (*models.DIDConfiguration, error)
+}
+
+type didClient interface {
+	CreateDID(domain string, opts ...didclient.CreateDIDOption) (*didclient.OperationResult, error)
 }
 
 type vdri interface {
-	Build(pubKey *vdriapi.PubKey, opts ...vdriapi.DocOpts) (*docdid.Doc, error)
 	Read(did string, opts ...vdriapi.ResolveOpts) (*docdid.Doc, error)
 }
 
 // VDRI bloc
 type VDRI struct {
-	resolverURL     string
-	endpointService endpointService
-	getHTTPVDRI     func(url string) (vdri, error) // needed for unit test
-	tlsConfig       *tls.Config
-	authToken       string
+	resolverURL      string
+	endpointService  endpointService
+	getHTTPVDRI      func(ctx context.Context, url string) (vdri, error) // needed for unit test
+	tlsConfig        *tls.Config
+	authToken        string
+	timeout          time.Duration
+	cache            *resolutionCache
+	cacheTTL         time.Duration
+	notFoundTTL      time.Duration
+	cacheMaxEntries  int
+	domain           string
+	didClient        didClient
+	writeAuthToken   string
+	revealValueStore storage.Store
+	transportOpts    []resilient.Option
+	configOpts       []httpconfig.Option
+	clientOpts       []didclient.Option
+	bootstrap        bool
 }
 
 // New creates new bloc vdri
 func New(opts ...Option) *VDRI {
-	v := &VDRI{}
+	v := &VDRI{timeout: defaultTimeout}
 
 	for _, opt := range opts {
 		opt(v)
 	}
 
-	configService := httpconfig.NewService(httpconfig.WithTLSConfig(v.tlsConfig))
-	verifyingService := verifyingconfig.NewService(configService)
-	v.endpointService = endpoint.NewService(
-		staticdiscovery.NewService(verifyingService),
-		staticselection.NewService(verifyingService))
+	if v.cacheTTL > 0 || v.notFoundTTL > 0 {
+		v.cache = newResolutionCache(v.cacheTTL, v.notFoundTTL, v.cacheMaxEntries)
+	}
 
-	v.getHTTPVDRI = func(url string) (vdri, error) {
-		return httpbinding.New(url,
-			httpbinding.WithTLSConfig(v.tlsConfig), httpbinding.WithResolveAuthToken(v.authToken))
+	configService := httpconfig.NewService(append([]httpconfig.Option{httpconfig.WithTLSConfig(v.tlsConfig),
+		httpconfig.WithTimeout(v.timeout)}, v.configOpts...)...)
+	v.endpointService = v.newEndpointService(verifyingconfig.NewService(configService))
+
+	client := &http.Client{Transport: resilient.NewTransport(&http.Transport{TLSClientConfig: v.tlsConfig},
+		v.transportOpts...), Timeout: v.timeout}
+
+	authToken := ""
+	if v.authToken != "" {
+		authToken = "Bearer " + v.authToken
+	}
+
+	v.getHTTPVDRI = func(ctx context.Context, url string) (vdri, error) {
+		return newHTTPResolver(ctx, url, client, authToken)
 	}
 
+	clientOpts := append([]didclient.Option{didclient.WithTLSConfig(v.tlsConfig),
+		didclient.WithTimeout(v.timeout)}, v.clientOpts...)
+	if v.writeAuthToken != "" {
+		clientOpts = append(clientOpts, didclient.WithAuthToken(v.writeAuthToken))
+	}
+
+	v.didClient = didclient.New(clientOpts...)
+
 	return v
 }
 
+// newEndpointService returns the endpoint service of the consortium configs, discovering the endpoints
+// of the verified stakeholders if automatic bootstrapping is enabled
+func (v *VDRI) newEndpointService(c configService) endpointService {
+	selectionService := staticselection.NewService(c)
+
+	if v.bootstrap {
+		return endpoint.NewService(bootstrapdiscovery.NewService(c, v.resolveFromEndpoint), selectionService)
+	}
+
+	return endpoint.NewService(staticdiscovery.NewService(c), selectionService)
+}
+
+// resolveFromEndpoint resolves the did with the identifiers path of the sidetree endpoint
+func (v *VDRI) resolveFromEndpoint(ctx context.Context, endpointURL, did string) (*docdid.Doc, error) {
+	return v.sidetreeResolve(ctx, endpointURL+identifiersPath, did)
+}
+
 // Accept did method
 func (v *VDRI) Accept(method string) bool {
 	return method == "trustbloc"
@@ -81,75 +151,287 @@ func (v *VDRI) Store(doc *docdid.Doc, by *[]vdriapi.ModifiedBy) error {
 	return nil
 }
 
-// Build did doc
-func (v *VDRI) Build(pubKey *vdriapi.PubKey, opts ...vdriapi.DocOpts) (*docdid.Doc, error) {
-	return nil, fmt.Errorf("build method not supported for did bloc")
-}
-
-func (v *VDRI) sidetreeResolve(url, did string, opts ...vdriapi.ResolveOpts) (*docdid.Doc, error) {
-	resolver, err := v.getHTTPVDRI(url)
+func (v *VDRI) sidetreeResolve(ctx context.Context, url, did string,
+	opts ...vdriapi.ResolveOpts) (*docdid.Doc, error) {
+	resolver, err := v.getHTTPVDRI(ctx, url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create new sidetree vdri: %w", err)
 	}
 
 	doc, err := resolver.Read(did, opts...)
 	if err != nil {
+		if isDeactivated(err) {
+			return nil, fmt.Errorf("%w: %s", ErrDIDDeactivated, did)
+		}
+
 		return nil, fmt.Errorf("failed to resolve did: %w", err)
 	}
 
 	return doc, nil
 }
 
-func (v *VDRI) Read(did string, opts ...vdriapi.ResolveOpts) (*docdid.Doc, error) { //nolint: gocyclo
-	if v.resolverURL != "" {
-		return v.sidetreeResolve(v.resolverURL, did, opts...)
+// isDeactivated reports whether the http binding resolver error is the sidetree response for a deactivated DID
+func isDeactivated(err error) bool {
+	return hasStatus(err, http.StatusGone)
+}
+
+// ResolutionResult holds a resolved did document with the metadata of its resolution.
+// The did document is nil if the did has been deactivated.
+type ResolutionResult struct {
+	DIDDocument      *docdid.Doc
+	ResolverMetadata models.ResolverMetadata
+	MethodMetadata   models.MethodMetaData
+}
+
+// Read resolves the did document. ErrDIDDeactivated is returned if the did has been deactivated.
+// The number of stakeholders required by the consortium policy must return the same document,
+// otherwise an InsufficientAgreementError is returned.
+func (v *VDRI) Read(did string, opts ...vdriapi.ResolveOpts) (*docdid.Doc, error) {
+	return v.ReadWithContext(context.Background(), did, opts...)
+}
+
+// ReadWithContext resolves the did document, the discovery and resolution requests are cancelled with the context
+func (v *VDRI) ReadWithContext(ctx context.Context, did string, opts ...vdriapi.ResolveOpts) (*docdid.Doc, error) {
+	result, err := v.ResolveWithContext(ctx, did, opts...)
+	if err != nil {
+		return nil, err
 	}
 
-	// parse did
-	didParts := strings.Split(did, ":")
-	if len(didParts) != 4 {
-		return nil, fmt.Errorf("wrong did %s", did)
+	if result.MethodMetadata.Deactivated {
+		return nil, fmt.Errorf("%w: %s", ErrDIDDeactivated, did)
 	}
 
-	endpoints, err := v.endpointService.GetEndpoints(didParts[2])
+	return result.DIDDocument, nil
+}
+
+// Resolve resolves the did document and returns it with the metadata of its resolution: the endpoints queried
+// and which of them agreed, the consortium config they were selected from and whether the did is deactivated.
+// Long-form dids are resolved through the consortium endpoints first, and the document is built from
+// the initial state embedded in the did (flagged as not published) if the did is not anchored yet.
+// Documents returned by the resolver url are reported as published.
+// Results are served from the resolution cache when it is enabled, unless vdriapi.WithNoCache is passed.
+// ErrVersionNotSupported is returned if a version of the document is requested with vdriapi.WithVersionID
+// or vdriapi.WithVersionTime.
+func (v *VDRI) Resolve(did string, opts ...vdriapi.ResolveOpts) (*ResolutionResult, error) {
+	return v.ResolveWithContext(context.Background(), did, opts...)
+}
+
+// ResolveWithContext resolves the did document and returns it with the metadata of its resolution,
+// the discovery and resolution requests are cancelled with the context
+func (v *VDRI) ResolveWithContext(ctx context.Context, did string,
+	opts ...vdriapi.ResolveOpts) (*ResolutionResult, error) {
+	start := time.Now()
+
+	result, cached, err := v.resolveWithCache(ctx, did, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get endpoints: %w", err)
+		return nil, err
 	}
 
-	if len(endpoints) == 0 {
-		return nil, errors.New("list of endpoints is empty")
+	result.ResolverMetadata = models.ResolverMetadata{Retrieved: start.UTC(),
+		Duration: time.Since(start).Milliseconds(), Cached: cached}
+
+	return result, nil
+}
+
+// CacheStats returns the counters of the resolution cache, they are zero if the cache is not enabled
+func (v *VDRI) CacheStats() CacheStats {
+	if v.cache == nil {
+		return CacheStats{}
 	}
 
-	var doc *docdid.Doc
+	return v.cache.stats()
+}
 
-	var docBytes []byte
+// resolveWithCache resolves the did from the cache if it is there. Calls with the no cache option skip
+// the lookup but still refresh the cache.
+func (v *VDRI) resolveWithCache(ctx context.Context, did string,
+	opts ...vdriapi.ResolveOpts) (*ResolutionResult, bool, error) {
+	resolveOpts := &vdriapi.ResolveDIDOpts{}
+	for _, opt := range opts {
+		opt(resolveOpts)
+	}
 
-	for _, e := range endpoints {
-		resp, err := v.sidetreeResolve(e.URL+"/identifiers", did, opts...)
-		if err != nil {
-			return nil, err
-		}
+	if resolveOpts.VersionID != nil || resolveOpts.VersionTime != "" {
+		return nil, false, fmt.Errorf("%w: %s", ErrVersionNotSupported, did)
+	}
 
-		respBytesRaw, err := resp.JSONBytes()
-		if err != nil {
-			return nil, fmt.Errorf("cannot marshal resolved doc: %w", err)
+	if v.cache == nil {
+		result, err := v.resolve(ctx, did, opts...)
+
+		return result, false, err
+	}
+
+	if !resolveOpts.NoCache {
+		if entry, ok := v.cache.get(did); ok {
+			if entry.err != nil {
+				return nil, true, entry.err
+			}
+
+			// copied so that the caller doesn't change the cached metadata
+			result := *entry.result
+
+			return &result, true, nil
 		}
+	}
+
+	result, err := v.resolve(ctx, did, opts...)
+
+	switch {
+	case err == nil:
+		cached := *result
+		v.cache.add(did, &cached, nil)
+	case isNotFound(err):
+		v.cache.add(did, nil, err)
+	}
+
+	return result, false, err
+}
+
+func (v *VDRI) resolve(ctx context.Context, did string, opts ...vdriapi.ResolveOpts) (*ResolutionResult, error) {
+	parsed, err := didclient.ParseDID(did)
+	if err != nil {
+		return nil, err
+	}
 
-		respBytes, err := jsoncanonicalizer.Transform(respBytesRaw)
+	if v.resolverURL != "" {
+		return v.resolveFromResolverURL(ctx, did, opts...)
+	}
+
+	shortFormDID, initialState := parsed.ShortForm(), parsed.InitialState
+
+	doc, metadata, err := v.resolveFromEndpoints(ctx, parsed, opts...)
+	if metadata == nil {
+		return nil, err
+	}
+
+	switch {
+	case err == nil:
+		metadata.Published = true
+	case errors.Is(err, ErrDIDDeactivated):
+		metadata.Deactivated = true
+	case initialState != "" && isNotFound(err):
+		doc, err = docFromInitialState(shortFormDID, initialState)
 		if err != nil {
-			return nil, fmt.Errorf("cannot canonicalize resolved doc: %w", err)
+			return nil, fmt.Errorf("failed to resolve did from initial state: %w", err)
 		}
+	default:
+		return nil, err
+	}
 
-		if doc != nil && !bytes.Equal(docBytes, respBytes) {
-			log.Debugf("mismatch in document contents for did %s. Doc 1: %s, Doc 2: %s",
-				did, string(docBytes), string(respBytes))
-		}
+	return &ResolutionResult{DIDDocument: doc, MethodMetadata: *metadata}, nil
+}
+
+func (v *VDRI) resolveFromResolverURL(ctx context.Context, did string,
+	opts ...vdriapi.ResolveOpts) (*ResolutionResult, error) {
+	start := time.Now()
+
+	doc, err := v.sidetreeResolve(ctx, v.resolverURL, did, opts...)
 
-		doc = resp
-		docBytes = respBytes
+	endpoint := models.EndpointMetadata{URL: v.resolverURL, Responded: true, Agreed: true,
+		Duration: time.Since(start).Milliseconds()}
+	metadata := models.MethodMetaData{RequiredAgreement: 1}
+
+	switch {
+	case err == nil:
+		metadata.Published = true
+	case errors.Is(err, ErrDIDDeactivated):
+		metadata.Deactivated = true
+		endpoint.Error = err.Error()
+	default:
+		return nil, err
 	}
 
-	return doc, nil
+	metadata.Endpoints = []models.EndpointMetadata{endpoint}
+
+	return &ResolutionResult{DIDDocument: doc, MethodMetadata: metadata}, nil
+}
+
+// isNotFound reports whether the http binding resolver error is the sidetree response for an unknown DID
+func isNotFound(err error) bool {
+	return errors.Is(err, vdriapi.ErrNotFound) || hasStatus(err, http.StatusNotFound)
+}
+
+// resolveFromEndpoints resolves the short-form did from the consortium endpoints. The method metadata is returned
+// with the agreed result, including the deactivated and not found errors.
+func (v *VDRI) resolveFromEndpoints(ctx context.Context, parsed *didclient.DID,
+	opts ...vdriapi.ResolveOpts) (*docdid.Doc, *models.MethodMetaData, error) {
+	did := parsed.ShortForm()
+
+	selected, err := v.endpointService.GetQuorumWithContext(ctx, parsed.Domain)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get endpoints: %w", err)
+	}
+
+	if len(selected.Endpoints) == 0 {
+		return nil, nil, errors.New("list of endpoints is empty")
+	}
+
+	resolveFromEndpoint := func(ctx context.Context, endpointURL string) (*docdid.Doc, error) {
+		return v.sidetreeResolve(ctx, endpointURL+identifiersPath, did, opts...)
+	}
+
+	q, result, err := v.resolveWithQuorum(ctx, did, selected, resolveFromEndpoint)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return result.doc, q.metadata(result, selected.ConsortiumHash), result.err
+}
+
+// resolveWithQuorum resolves the did from all the endpoints concurrently and returns as soon as the required
+// number of them agree, the outstanding requests are then cancelled. Endpoints that haven't responded within
+// the timeout are counted as failures.
+func (v *VDRI) resolveWithQuorum(parent context.Context, did string, selected *models.Quorum,
+	resolveFromEndpoint func(ctx context.Context, endpointURL string) (*docdid.Doc, error)) (*quorum,
+	*quorumResult, error) {
+	ctx, cancel := context.WithTimeout(parent, v.timeout)
+	defer cancel()
+
+	// buffered so that the responses arriving after quorum is reached are dropped
+	results := make(chan *endpointResult, len(selected.Endpoints))
+	pending := make(map[string]bool)
+
+	for _, e := range selected.Endpoints {
+		pending[e.URL] = true
+
+		go func(endpointURL string) {
+			start := time.Now()
+			doc, err := resolveFromEndpoint(ctx, endpointURL)
+			results <- &endpointResult{endpointURL: endpointURL, doc: doc, err: err,
+				duration: time.Since(start), responded: true}
+		}(e.URL)
+	}
+
+	q := newQuorum(did, selected.Required, selected.Endpoints)
+
+	for {
+		select {
+		case r := <-results:
+			delete(pending, r.endpointURL)
+
+			result, err := q.add(r)
+			if err != nil || result != nil {
+				return q, result, err
+			}
+		case <-ctx.Done():
+			if parent.Err() != nil {
+				return q, nil, fmt.Errorf("failed to resolve did %s: %w", did, parent.Err())
+			}
+
+			for _, e := range selected.Endpoints {
+				if !pending[e.URL] {
+					continue
+				}
+
+				// a failure never completes the quorum, the last one fails it
+				_, err := q.add(&endpointResult{endpointURL: e.URL, err: fmt.Errorf("timed out after %s", v.timeout),
+					duration: v.timeout})
+				if err != nil {
+					return q, nil, err
+				}
+			}
+		}
+	}
 }
 
 // Option configures the bloc vdri
@@ -169,9 +451,79 @@ func WithTLSConfig(tlsConfig *tls.Config) Option {
 	}
 }
 
+// WithTimeout option sets the time to wait for the endpoints to resolve a did
+func WithTimeout(timeout time.Duration) Option {
+	return func(opts *VDRI) {
+		opts.timeout = timeout
+	}
+}
+
+// WithRetry option retries the resolution and config requests failing with transport errors, 429 or 5xx
+// responses up to max retries times, with a backoff doubling from the initial backoff up to the max backoff.
+// The retries of an endpoint count towards the resolution timeout.
+func WithRetry(maxRetries int, initialBackoff, maxBackoff time.Duration) Option {
+	return func(opts *VDRI) {
+		opts.transportOpts = append(opts.transportOpts, resilient.WithRetry(maxRetries, initialBackoff, maxBackoff))
+		opts.configOpts = append(opts.configOpts, httpconfig.WithRetry(maxRetries, initialBackoff, maxBackoff))
+		opts.clientOpts = append(opts.clientOpts, didclient.WithRetry(maxRetries, initialBackoff, maxBackoff))
+	}
+}
+
+// WithCircuitBreaker option skips the sidetree and config endpoints failing failure threshold times in a row
+// for the open timeout, they are counted as failed endpoints in the meantime
+func WithCircuitBreaker(failureThreshold int, openTimeout time.Duration) Option {
+	return func(opts *VDRI) {
+		opts.transportOpts = append(opts.transportOpts, resilient.WithCircuitBreaker(failureThreshold, openTimeout))
+		opts.configOpts = append(opts.configOpts, httpconfig.WithCircuitBreaker(failureThreshold, openTimeout))
+		opts.clientOpts = append(opts.clientOpts, didclient.WithCircuitBreaker(failureThreshold, openTimeout))
+	}
+}
+
+// WithCache option enables the resolution cache. Resolved documents are kept for the ttl, not found errors
+// and documents built from the initial state of long-form dids for the not found ttl (not kept if zero).
+// The least recently used entries are evicted beyond max entries (1000 if zero).
+func WithCache(ttl, notFoundTTL time.Duration, maxEntries int) Option {
+	return func(opts *VDRI) {
+		opts.cacheTTL = ttl
+		opts.notFoundTTL = notFoundTTL
+		opts.cacheMaxEntries = maxEntries
+	}
+}
+
+// WithAutomaticBootstrapping option verifies the stakeholders before using their endpoints: the key listed for
+// each stakeholder in the consortium config must be expressed by its DID document, resolved with its own endpoints,
+// and sign the assertion linking the DID to its domain in the well-known DID configuration.
+// Stakeholders failing verification are replaced by others, and discovery fails if too few of them are verified.
+func WithAutomaticBootstrapping() Option {
+	return func(opts *VDRI) {
+		opts.bootstrap = true
+	}
+}
+
 // WithAuthToken add auth token
 func WithAuthToken(authToken string) Option {
 	return func(opts *VDRI) {
 		opts.authToken = authToken
 	}
 }
+
+// WithDomain option sets the consortium domain the dids are created in by Build
+func WithDomain(domain string) Option {
+	return func(opts *VDRI) {
+		opts.domain = domain
+	}
+}
+
+// WithRevealValueStore option sets the store the reveal values of the dids created by Build are put in
+func WithRevealValueStore(store storage.Store) Option {
+	return func(opts *VDRI) {
+		opts.revealValueStore = store
+	}
+}
+
+// WithWriteAuthToken option sets the auth token of the sidetree create requests sent by Build
+func WithWriteAuthToken(authToken string) Option {
+	return func(opts *VDRI) {
+		opts.writeAuthToken = authToken
+	}
+}
@@ -6,17 +6,38 @@ SPDX-License-Identifier: Apache-2.0
 package trustbloc
 
 import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
 	"crypto/tls"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/btcsuite/btcutil/base58"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
 	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+	mockstorage "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
 	mockvdri "github.com/hyperledger/aries-framework-go/pkg/mock/vdri"
 	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/protocol"
+	"github.com/trustbloc/sidetree-core-go/pkg/composer"
+	"github.com/trustbloc/sidetree-core-go/pkg/dochandler/didvalidator"
+	"github.com/trustbloc/sidetree-core-go/pkg/document"
+	"github.com/trustbloc/sidetree-core-go/pkg/operation"
+	"github.com/trustbloc/sidetree-core-go/pkg/restapi/model"
 
+	didclient "github.com/trustbloc/trustbloc-did-method/pkg/did"
+	mockconfig "github.com/trustbloc/trustbloc-did-method/pkg/internal/mock/config"
+	"github.com/trustbloc/trustbloc-did-method/pkg/internal/mock/didbloc"
 	mockendpoint "github.com/trustbloc/trustbloc-did-method/pkg/internal/mock/endpoint"
+	mockmodels "github.com/trustbloc/trustbloc-did-method/pkg/internal/mock/models"
 	"github.com/trustbloc/trustbloc-did-method/pkg/vdri/trustbloc/models"
 )
 
@@ -41,11 +62,105 @@ func TestVDRI_Store(t *testing.T) {
 }
 
 func TestVDRI_Build(t *testing.T) {
-	t.Run("test error", func(t *testing.T) {
+	pubKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	t.Run("test domain not configured", func(t *testing.T) {
 		v := New()
-		_, err := v.Build(nil)
+		_, err := v.Build(&vdriapi.PubKey{Value: base58.Encode(pubKey)})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "consortium domain is not configured")
+	})
+
+	t.Run("test reveal value store not configured", func(t *testing.T) {
+		v := New(WithDomain("testnet"))
+		_, err := v.Build(&vdriapi.PubKey{Value: base58.Encode(pubKey)})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "reveal value store is not configured")
+	})
+
+	t.Run("test error from create did", func(t *testing.T) {
+		v := New(WithDomain("testnet"), WithRevealValueStore(&mockstorage.MockStore{}))
+		v.didClient = &didbloc.Client{CreateDIDErr: errors.New("create error")}
+
+		_, err := v.Build(&vdriapi.PubKey{Value: base58.Encode(pubKey)})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to create did: create error")
+
+		_, err = v.Build(&vdriapi.PubKey{Value: base58.Encode(pubKey), Type: "JwsVerificationKey2020"})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "public key type not supported")
+
+		_, err = v.Build(&vdriapi.PubKey{Value: base58.Encode(pubKey), Type: "X25519KeyAgreementKey2019"})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "public key type not supported: X25519KeyAgreementKey2019")
+
+		_, err = v.Build(nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "public key is required")
+
+		v.didClient = &didbloc.Client{}
+
+		_, err = v.Build(&vdriapi.PubKey{Value: base58.Encode(pubKey)})
 		require.Error(t, err)
-		require.Contains(t, err.Error(), "build method not supported for did bloc")
+		require.Contains(t, err.Error(), "the sidetree node didn't return the did document")
+	})
+
+	t.Run("test error from reveal value store", func(t *testing.T) {
+		v := New(WithDomain("testnet"), WithRevealValueStore(&mockstorage.MockStore{ErrPut: errors.New("put error")}))
+		v.didClient = &didbloc.Client{CreateDIDValue: &did.Doc{ID: "did:trustbloc:testnet:123"}}
+
+		_, err := v.Build(&vdriapi.PubKey{Value: base58.Encode(pubKey)})
+		require.Error(t, err)
+		require.Contains(t, err.Error(),
+			"did did:trustbloc:testnet:123 was created but failed to store its reveal values: put error")
+	})
+
+	t.Run("test success", func(t *testing.T) {
+		store := &mockstorage.MockStore{Store: map[string][]byte{}}
+
+		v := New(WithDomain("testnet"), WithRevealValueStore(store))
+		v.didClient = &didbloc.Client{CreateDIDValue: &did.Doc{ID: "did:trustbloc:testnet:123"}}
+
+		doc, err := v.Build(&vdriapi.PubKey{Value: base58.Encode(pubKey), Type: "Ed25519VerificationKey2018"},
+			vdriapi.WithServiceType(vdriapi.DIDCommServiceType), vdriapi.WithServiceEndpoint("https://agent"))
+		require.NoError(t, err)
+		require.Equal(t, "did:trustbloc:testnet:123", doc.ID)
+
+		stored, err := store.Get("did:trustbloc:testnet:123")
+		require.NoError(t, err)
+
+		var revealValues RevealValues
+		require.NoError(t, json.Unmarshal(stored, &revealValues))
+		require.Equal(t, []byte("update"), revealValues.UpdateRevealValue)
+		require.Equal(t, []byte("recovery"), revealValues.RecoveryRevealValue)
+	})
+
+	t.Run("test document", func(t *testing.T) {
+		publicKeys, services, err := buildDocument(&vdriapi.PubKey{Value: base58.Encode(pubKey)},
+			&vdriapi.CreateDIDOpts{ServiceType: vdriapi.DIDCommServiceType, ServiceEndpoint: "https://agent",
+				RoutingKeys: []string{"routing"}})
+		require.NoError(t, err)
+		require.Len(t, publicKeys, 2)
+		require.Equal(t, didclient.Ed25519VerificationKey2018, publicKeys[0].Type)
+		require.Equal(t, didclient.Ed25519VerificationKey2018, publicKeys[1].Type)
+		require.Equal(t, didclient.Ed25519KeyType, publicKeys[0].KeyType)
+		require.Equal(t, []string{didclient.KeyUsageGeneral}, publicKeys[0].Usage)
+		require.Equal(t, []byte(pubKey), publicKeys[0].Value)
+		require.False(t, publicKeys[0].Recovery)
+		require.True(t, publicKeys[1].Recovery)
+		require.Len(t, services, 1)
+		require.Equal(t, "https://agent", services[0].ServiceEndpoint)
+		require.Equal(t, []string{"routing"}, services[0].RoutingKeys)
+		require.Equal(t, []string{base58.Encode(pubKey)}, services[0].RecipientKeys)
+
+		publicKeys, services, err = buildDocument(&vdriapi.PubKey{Value: base58.Encode(pubKey),
+			Type: "EcdsaSecp256k1VerificationKey2019"}, &vdriapi.CreateDIDOpts{})
+		require.NoError(t, err)
+		require.Equal(t, didclient.EcdsaSecp256k1VerificationKey2019, publicKeys[0].Type)
+		require.Equal(t, didclient.Secp256k1KeyType, publicKeys[0].KeyType)
+		require.Equal(t, didclient.Secp256k1KeyType, publicKeys[1].KeyType)
+		require.Empty(t, services)
 	})
 }
 
@@ -53,15 +168,15 @@ func TestVDRI_Read(t *testing.T) {
 	t.Run("test error from get http vdri for resolver url", func(t *testing.T) {
 		v := New(WithResolverURL("url"))
 
-		_, err := v.getHTTPVDRI("")
+		_, err := v.getHTTPVDRI(context.Background(), "")
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "empty url")
 
-		v.getHTTPVDRI = func(url string) (v vdri, err error) {
+		v.getHTTPVDRI = func(ctx context.Context, url string) (v vdri, err error) {
 			return nil, fmt.Errorf("get http vdri error")
 		}
 
-		doc, err := v.Read("did")
+		doc, err := v.Read("did:trustbloc:testnet:123")
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "get http vdri error")
 		require.Nil(t, doc)
@@ -70,14 +185,14 @@ func TestVDRI_Read(t *testing.T) {
 	t.Run("test error from http vdri build for resolver url", func(t *testing.T) {
 		v := New(WithResolverURL("url"))
 
-		v.getHTTPVDRI = func(url string) (v vdri, err error) {
+		v.getHTTPVDRI = func(ctx context.Context, url string) (v vdri, err error) {
 			return &mockvdri.MockVDRI{
 				ReadFunc: func(didID string, opts ...vdriapi.ResolveOpts) (*did.Doc, error) {
 					return nil, fmt.Errorf("read error")
 				}}, nil
 		}
 
-		doc, err := v.Read("did")
+		doc, err := v.Read("did:trustbloc:testnet:123")
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "read error")
 		require.Nil(t, doc)
@@ -86,28 +201,55 @@ func TestVDRI_Read(t *testing.T) {
 	t.Run("test success for resolver url", func(t *testing.T) {
 		v := New(WithResolverURL("url"))
 
-		v.getHTTPVDRI = func(url string) (v vdri, err error) {
+		v.getHTTPVDRI = func(ctx context.Context, url string) (v vdri, err error) {
 			return &mockvdri.MockVDRI{
 				ReadFunc: func(didID string, opts ...vdriapi.ResolveOpts) (*did.Doc, error) {
 					return &did.Doc{ID: "did"}, nil
 				}}, nil
 		}
 
-		doc, err := v.Read("did")
+		doc, err := v.Read("did:trustbloc:testnet:123")
 		require.NoError(t, err)
 		require.Equal(t, "did", doc.ID)
+
+		result, err := v.Resolve("did:trustbloc:testnet:123")
+		require.NoError(t, err)
+		require.True(t, result.MethodMetadata.Published)
+		require.Equal(t, 1, result.MethodMetadata.RequiredAgreement)
+		require.Equal(t, "url", result.MethodMetadata.Endpoints[0].URL)
+	})
+
+	t.Run("test deactivated did for resolver url", func(t *testing.T) {
+		v := New(WithResolverURL("url"))
+
+		v.getHTTPVDRI = func(ctx context.Context, url string) (v vdri, err error) {
+			return &mockvdri.MockVDRI{
+				ReadFunc: func(didID string, opts ...vdriapi.ResolveOpts) (*did.Doc, error) {
+					return nil, &statusError{statusCode: http.StatusGone}
+				}}, nil
+		}
+
+		doc, err := v.Read("did:trustbloc:testnet:123")
+		require.Error(t, err)
+		require.True(t, errors.Is(err, ErrDIDDeactivated))
+		require.Nil(t, doc)
+
+		result, err := v.Resolve("did:trustbloc:testnet:123")
+		require.NoError(t, err)
+		require.True(t, result.MethodMetadata.Deactivated)
+		require.Contains(t, result.MethodMetadata.Endpoints[0].Error, "did has been deactivated")
 	})
 
 	t.Run("test error parsing did", func(t *testing.T) {
 		v := New()
 
-		v.getHTTPVDRI = func(url string) (v vdri, err error) {
+		v.getHTTPVDRI = func(ctx context.Context, url string) (v vdri, err error) {
 			return nil, nil
 		}
 
 		doc, err := v.Read("did:1223")
 		require.Error(t, err)
-		require.Contains(t, err.Error(), "wrong did did:1223")
+		require.Contains(t, err.Error(), "invalid did did:1223")
 		require.Nil(t, doc)
 	})
 
@@ -153,7 +295,7 @@ func TestVDRI_Read(t *testing.T) {
 				return []*models.Endpoint{{URL: "url"}}, nil
 			}}
 
-		v.getHTTPVDRI = func(url string) (v vdri, err error) {
+		v.getHTTPVDRI = func(ctx context.Context, url string) (v vdri, err error) {
 			return nil, fmt.Errorf("get http vdri error")
 		}
 
@@ -171,7 +313,7 @@ func TestVDRI_Read(t *testing.T) {
 				return []*models.Endpoint{{URL: "url"}}, nil
 			}}
 
-		v.getHTTPVDRI = func(url string) (v vdri, err error) {
+		v.getHTTPVDRI = func(ctx context.Context, url string) (v vdri, err error) {
 			return &mockvdri.MockVDRI{
 				ReadFunc: func(didID string, opts ...vdriapi.ResolveOpts) (*did.Doc, error) {
 					return nil, fmt.Errorf("read error")
@@ -184,29 +326,271 @@ func TestVDRI_Read(t *testing.T) {
 		require.Nil(t, doc)
 	})
 
-	//nolint:gocritic
-	// t.Run("test error from mismatch", func(t *testing.T) {
-	// 	v := New()
-	//
-	// 	v.endpointService = &mockendpoint.MockEndpointService{
-	// 		GetEndpointsFunc: func(domain string) (endpoints []*models.Endpoint, err error) {
-	// 			return []*models.Endpoint{{URL: "url"}, {URL: "url.2"}}, nil
-	// 		}}
-	//
-	// 	counter := 0
-	//
-	// 	v.getHTTPVDRI = func(url string) (v vdri, err error) {
-	// 		return &mockvdri.MockVDRI{
-	// 			ReadFunc: func(didID string, opts ...vdriapi.ResolveOpts) (*did.Doc, error) {
-	// 				counter++
-	// 				return generateDIDDoc("test:" + string(counter)), nil
-	// 			}}, nil
-	// 	}
-	//
-	// 	_, err := v.Read("did:trustbloc:testnet:123")
-	// 	require.Error(t, err)
-	// 	require.Contains(t, err.Error(), "mismatch")
-	// })
+	t.Run("test deactivated did", func(t *testing.T) {
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusGone)
+			_, err := w.Write([]byte("document is no longer available"))
+			require.NoError(t, err)
+		}))
+		defer serv.Close()
+
+		v := New()
+
+		v.endpointService = &mockendpoint.MockEndpointService{
+			GetEndpointsFunc: func(domain string) (endpoints []*models.Endpoint, err error) {
+				return []*models.Endpoint{{URL: serv.URL}}, nil
+			}}
+
+		doc, err := v.Read("did:trustbloc:testnet:123")
+		require.Error(t, err)
+		require.True(t, errors.Is(err, ErrDIDDeactivated))
+		require.Nil(t, doc)
+	})
+
+	t.Run("test long-form did", func(t *testing.T) {
+		pubKey, _, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		computed, err := didclient.New().ComputeDID("testnet", didclient.WithPublicKey(&didclient.PublicKey{
+			ID: "key1", Type: didclient.JWSVerificationKey2020, Encoding: didclient.PublicKeyEncodingJwk,
+			KeyType: didclient.Ed25519KeyType, Value: pubKey, Usage: []string{didclient.KeyUsageGeneral}}),
+			didclient.WithPublicKey(&didclient.PublicKey{Encoding: didclient.PublicKeyEncodingJwk,
+				KeyType: didclient.Ed25519KeyType, Value: pubKey, Recovery: true}))
+		require.NoError(t, err)
+
+		anchored := false
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NotContains(t, r.URL.String(), "initial-state")
+
+			if !anchored {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			w.Header().Set("Content-type", "application/did+ld+json")
+			_, err := w.Write([]byte(`{"@context":"https://w3id.org/did/v1","id":"` + computed.ShortForm + `"}`))
+			require.NoError(t, err)
+		}))
+		defer serv.Close()
+
+		v := New()
+
+		v.endpointService = &mockendpoint.MockEndpointService{
+			GetEndpointsFunc: func(domain string) (endpoints []*models.Endpoint, err error) {
+				return []*models.Endpoint{{URL: serv.URL}}, nil
+			}}
+
+		// not anchored yet
+		result, err := v.Resolve(computed.LongForm)
+		require.NoError(t, err)
+		require.False(t, result.MethodMetadata.Published)
+		require.Equal(t, computed.ShortForm, result.DIDDocument.ID)
+		require.Len(t, result.DIDDocument.PublicKey, 1)
+		require.Equal(t, computed.ShortForm+"#key1", result.DIDDocument.PublicKey[0].ID)
+
+		doc, err := v.Read(computed.LongForm)
+		require.NoError(t, err)
+		require.Equal(t, computed.ShortForm, doc.ID)
+
+		_, err = v.Read(computed.ShortForm)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "DID does not exist")
+
+		_, err = v.Read("did:trustbloc:testnet:123" + computed.LongForm[len(computed.ShortForm):])
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "doesn't match the did created from initial state")
+
+		_, err = v.Read(computed.ShortForm + "?-trustbloc-initial-state=abc")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "initial state should have two parts")
+
+		_, err = v.Read(computed.ShortForm + "?-trustbloc-initial-state=")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "initial state is empty")
+
+		// anchored
+		anchored = true
+
+		result, err = v.Resolve(computed.LongForm)
+		require.NoError(t, err)
+		require.True(t, result.MethodMetadata.Published)
+		require.Equal(t, computed.ShortForm, result.DIDDocument.ID)
+	})
+
+	t.Run("test X25519 key agreement key round trip", func(t *testing.T) {
+		pubKey, _, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		x25519PubKey := make([]byte, 32)
+		_, err = rand.Read(x25519PubKey)
+		require.NoError(t, err)
+
+		computed, err := didclient.New().ComputeDID("testnet", didclient.WithPublicKey(&didclient.PublicKey{
+			ID: "key1", Type: didclient.X25519KeyAgreementKey2019, Encoding: didclient.PublicKeyEncodingJwk,
+			KeyType: didclient.X25519KeyType, Value: x25519PubKey,
+			Usage: []string{didclient.KeyUsageGeneral, didclient.KeyUsageAgreement}}),
+			didclient.WithPublicKey(&didclient.PublicKey{Encoding: didclient.PublicKeyEncodingJwk,
+				KeyType: didclient.Ed25519KeyType, Value: pubKey, Recovery: true}))
+		require.NoError(t, err)
+
+		anchoredDoc := sidetreeDocument(t, computed.LongForm)
+
+		anchored := false
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !anchored {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			w.Header().Set("Content-type", didLDJson)
+			_, err := w.Write(anchoredDoc)
+			require.NoError(t, err)
+		}))
+		defer serv.Close()
+
+		v := New()
+
+		v.endpointService = &mockendpoint.MockEndpointService{
+			GetEndpointsFunc: func(domain string) (endpoints []*models.Endpoint, err error) {
+				return []*models.Endpoint{{URL: serv.URL}}, nil
+			}}
+
+		for _, anchored = range []bool{false, true} {
+			result, err := v.Resolve(computed.LongForm)
+			require.NoError(t, err)
+			require.Equal(t, anchored, result.MethodMetadata.Published)
+			require.Len(t, result.DIDDocument.PublicKey, 1)
+			require.Equal(t, computed.ShortForm+"#key1", result.DIDDocument.PublicKey[0].ID)
+			require.Equal(t, didclient.X25519KeyAgreementKey2019, result.DIDDocument.PublicKey[0].Type)
+			require.Equal(t, x25519PubKey, result.DIDDocument.PublicKey[0].Value)
+		}
+	})
+
+	t.Run("test error from mismatch", func(t *testing.T) {
+		v := New()
+
+		v.endpointService = &mockendpoint.MockEndpointService{
+			GetEndpointsFunc: func(domain string) (endpoints []*models.Endpoint, err error) {
+				return []*models.Endpoint{{URL: "url"}, {URL: "url.2"}}, nil
+			}}
+
+		v.getHTTPVDRI = func(ctx context.Context, url string) (v vdri, err error) {
+			return &mockvdri.MockVDRI{
+				ReadFunc: func(didID string, opts ...vdriapi.ResolveOpts) (*did.Doc, error) {
+					return &did.Doc{ID: "did:trustbloc:testnet:" + url}, nil
+				}}, nil
+		}
+
+		_, err := v.Read("did:trustbloc:testnet:123")
+		require.Error(t, err)
+
+		var agreementErr *InsufficientAgreementError
+		require.True(t, errors.As(err, &agreementErr))
+		require.Equal(t, 2, agreementErr.Required)
+		require.Equal(t, 1, agreementErr.Agreed)
+		require.Contains(t, err.Error(), "insufficient agreement for did did:trustbloc:testnet:123")
+	})
+
+	t.Run("test quorum", func(t *testing.T) {
+		// endpoints without a document or an error don't respond until the test ends
+		release := make(chan struct{})
+		defer close(release)
+
+		newVDRI := func(docs map[string]*did.Doc, errs map[string]error) *VDRI {
+			v := New()
+
+			v.endpointService = &mockendpoint.MockEndpointService{
+				GetQuorumFunc: func(domain string) (*models.Quorum, error) {
+					return &models.Quorum{Endpoints: []*models.Endpoint{{URL: "url.1", Domain: "stakeholder.1"},
+						{URL: "url.2"}, {URL: "url.3"}, {URL: "url.4"}}, Required: 2, ConsortiumHash: "hash"}, nil
+				}}
+
+			v.getHTTPVDRI = func(ctx context.Context, url string) (vdri, error) {
+				return &mockvdri.MockVDRI{
+					ReadFunc: func(didID string, opts ...vdriapi.ResolveOpts) (*did.Doc, error) {
+						if docs[url] == nil && errs[url] == nil {
+							<-release
+						}
+
+						if docs[url] != nil && len(errs) != 0 {
+							// failures are reported first
+							time.Sleep(10 * time.Millisecond)
+						}
+
+						return docs[url], errs[url]
+					}}, nil
+			}
+
+			return v
+		}
+
+		// tolerates a failed endpoint and doesn't wait for the slow ones once quorum is reached
+		v := newVDRI(map[string]*did.Doc{
+			"url.2/identifiers": {ID: "did:trustbloc:testnet:123"},
+			"url.3/identifiers": {ID: "did:trustbloc:testnet:123"},
+		}, map[string]error{"url.1/identifiers": errors.New("connection refused")})
+
+		result, err := v.Resolve("did:trustbloc:testnet:123")
+		require.NoError(t, err)
+		require.Equal(t, "did:trustbloc:testnet:123", result.DIDDocument.ID)
+		require.False(t, result.ResolverMetadata.Retrieved.IsZero())
+
+		metadata := result.MethodMetadata
+		require.True(t, metadata.Published)
+		require.False(t, metadata.Deactivated)
+		require.Equal(t, "hash", metadata.ConsortiumHash)
+		require.Equal(t, 2, metadata.RequiredAgreement)
+		require.Len(t, metadata.Endpoints, 4)
+		require.Equal(t, models.EndpointMetadata{URL: "url.1", Stakeholder: "stakeholder.1", Responded: true,
+			Error: "failed to resolve did: connection refused"}, metadata.Endpoints[0])
+		require.True(t, metadata.Endpoints[1].Agreed)
+		require.True(t, metadata.Endpoints[2].Agreed)
+		require.Equal(t, models.EndpointMetadata{URL: "url.4"}, metadata.Endpoints[3])
+
+		// fails as soon as quorum can't be reached anymore
+		v = newVDRI(nil, map[string]error{
+			"url.1/identifiers": errors.New("connection refused"),
+			"url.2/identifiers": errors.New("timeout"),
+			"url.3/identifiers": errors.New("bad gateway"),
+		})
+
+		_, err = v.Read("did:trustbloc:testnet:123")
+		require.Error(t, err)
+
+		var agreementErr *InsufficientAgreementError
+		require.True(t, errors.As(err, &agreementErr))
+		require.Len(t, agreementErr.Failures, 3)
+		require.Contains(t, err.Error(), "endpoint url.1: failed to resolve did: connection refused")
+		require.Contains(t, err.Error(), "endpoint url.2: failed to resolve did: timeout")
+
+		// counts the endpoints that don't respond in time as failures
+		v = newVDRI(map[string]*did.Doc{"url.1/identifiers": {ID: "did:trustbloc:testnet:123"}}, nil)
+		v.timeout = 50 * time.Millisecond
+
+		_, err = v.Read("did:trustbloc:testnet:123")
+		require.Error(t, err)
+		require.True(t, errors.As(err, &agreementErr))
+		require.Equal(t, 1, agreementErr.Agreed)
+		require.Contains(t, err.Error(), "timed out after 50ms")
+
+		// stakeholders agreeing on a deactivated did
+		v = newVDRI(nil, map[string]error{
+			"url.1/identifiers": &statusError{statusCode: http.StatusGone},
+			"url.2/identifiers": &statusError{statusCode: http.StatusGone},
+		})
+
+		_, err = v.Read("did:trustbloc:testnet:123")
+		require.Error(t, err)
+		require.True(t, errors.Is(err, ErrDIDDeactivated))
+
+		result, err = v.Resolve("did:trustbloc:testnet:123")
+		require.NoError(t, err)
+		require.Nil(t, result.DIDDocument)
+		require.True(t, result.MethodMetadata.Deactivated)
+		require.False(t, result.MethodMetadata.Published)
+		require.True(t, result.MethodMetadata.Endpoints[0].Agreed)
+		require.True(t, result.MethodMetadata.Endpoints[1].Agreed)
+	})
 
 	t.Run("test success", func(t *testing.T) {
 		v := New()
@@ -216,7 +600,7 @@ func TestVDRI_Read(t *testing.T) {
 				return []*models.Endpoint{{URL: "url"}, {URL: "url.2"}}, nil
 			}}
 
-		v.getHTTPVDRI = func(url string) (v vdri, err error) {
+		v.getHTTPVDRI = func(ctx context.Context, url string) (v vdri, err error) {
 			return &mockvdri.MockVDRI{
 				ReadFunc: func(didID string, opts ...vdriapi.ResolveOpts) (*did.Doc, error) {
 					return &did.Doc{ID: "did:trustbloc:testnet:123"}, nil
@@ -229,6 +613,514 @@ func TestVDRI_Read(t *testing.T) {
 	})
 }
 
+func TestVDRI_Dereference(t *testing.T) {
+	const didID = "did:trustbloc:dom:abc"
+
+	doc := &did.Doc{ID: didID, Context: []string{"https://w3id.org/did/v1"},
+		PublicKey: []did.PublicKey{{ID: didID + "#key-1", Type: "Ed25519VerificationKey2018",
+			Controller: didID, Value: []byte("value")}},
+		KeyAgreement: []did.VerificationMethod{{PublicKey: did.PublicKey{ID: "#key-2",
+			Type: "X25519KeyAgreementKey2019", Controller: didID, Value: []byte("value")}, Embedded: true}},
+		Service: []did.Service{{ID: didID + "#hub", Type: "hub", ServiceEndpoint: "https://hub.example.com/base/"}}}
+
+	v := New(WithResolverURL("url"))
+	v.getHTTPVDRI = func(ctx context.Context, url string) (v vdri, err error) {
+		return &mockvdri.MockVDRI{
+			ReadFunc: func(didID string, opts ...vdriapi.ResolveOpts) (*did.Doc, error) {
+				return doc, nil
+			}}, nil
+	}
+
+	t.Run("test did", func(t *testing.T) {
+		result, err := v.Dereference(didID)
+		require.NoError(t, err)
+		require.Equal(t, doc, result.DIDDocument)
+		require.Nil(t, result.PublicKey)
+		require.Nil(t, result.Service)
+	})
+
+	t.Run("test verification method fragment", func(t *testing.T) {
+		result, err := v.Dereference(didID + "#key-1")
+		require.NoError(t, err)
+		require.Equal(t, didID+"#key-1", result.PublicKey.ID)
+
+		bytes, err := result.JSONBytes()
+		require.NoError(t, err)
+		require.Contains(t, string(bytes), `"id":"`+didID+`#key-1"`)
+
+		result, err = v.Dereference(didID + "#key-2")
+		require.NoError(t, err)
+		require.Equal(t, "#key-2", result.PublicKey.ID)
+
+		bytes, err = result.JSONBytes()
+		require.NoError(t, err)
+		require.Contains(t, string(bytes), "X25519KeyAgreementKey2019")
+	})
+
+	t.Run("test service fragment", func(t *testing.T) {
+		result, err := v.Dereference(didID + "#hub")
+		require.NoError(t, err)
+		require.Equal(t, didID+"#hub", result.Service.ID)
+
+		bytes, err := result.JSONBytes()
+		require.NoError(t, err)
+		require.Contains(t, string(bytes), "https://hub.example.com/base/")
+	})
+
+	t.Run("test service param", func(t *testing.T) {
+		result, err := v.Dereference(didID + "?service=hub")
+		require.NoError(t, err)
+		require.Equal(t, didID+"#hub", result.Service.ID)
+		require.Empty(t, result.Redirect)
+
+		result, err = v.Dereference(didID + "?service=hub&relativeRef=%2Fpath%3Fa%3D1#frag")
+		require.NoError(t, err)
+		require.Equal(t, "https://hub.example.com/path?a=1#frag", result.Redirect)
+
+		_, err = result.JSONBytes()
+		require.Error(t, err)
+
+		result, err = v.Dereference(didID + "?service=hub&relativeRef=path")
+		require.NoError(t, err)
+		require.Equal(t, "https://hub.example.com/base/path", result.Redirect)
+	})
+
+	t.Run("test not found", func(t *testing.T) {
+		_, err := v.Dereference(didID + "#key-3")
+		require.True(t, errors.Is(err, ErrFragmentNotFound))
+
+		_, err = v.Dereference(didID + "?service=agent")
+		require.True(t, errors.Is(err, ErrServiceNotFound))
+	})
+
+	t.Run("test invalid did url", func(t *testing.T) {
+		_, err := v.Dereference(didID + "/path")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "did url path is not supported")
+
+		_, err = v.Dereference(didID + "?versionId=1")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "did url parameter is not supported: versionId")
+
+		_, err = v.Dereference(didID + "?relativeRef=path")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "requires the service parameter")
+
+		_, err = v.Dereference(didID + "?service=%zz")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid did url query")
+	})
+
+	t.Run("test initial state is kept in the did", func(t *testing.T) {
+		parsed, err := parseDIDURL(didID + "?-trustbloc-initial-state=state#key-1")
+		require.NoError(t, err)
+		require.Equal(t, didID+"?"+didclient.InitialStateParam+"=state", parsed.did)
+		require.Equal(t, "key-1", parsed.fragment)
+	})
+
+	t.Run("test deactivated did", func(t *testing.T) {
+		v := New(WithResolverURL("url"))
+		v.getHTTPVDRI = func(ctx context.Context, url string) (v vdri, err error) {
+			return &mockvdri.MockVDRI{
+				ReadFunc: func(didID string, opts ...vdriapi.ResolveOpts) (*did.Doc, error) {
+					return nil, &statusError{statusCode: http.StatusGone}
+				}}, nil
+		}
+
+		_, err := v.Dereference(didID + "#key-1")
+		require.True(t, errors.Is(err, ErrDIDDeactivated))
+	})
+}
+
+func TestVDRI_Cache(t *testing.T) {
+	newCachedVDRI := func(read func(didID string) (*did.Doc, error), reads *int, opts ...Option) *VDRI {
+		v := New(append([]Option{WithResolverURL("url")}, opts...)...)
+		v.getHTTPVDRI = func(ctx context.Context, url string) (v vdri, err error) {
+			return &mockvdri.MockVDRI{
+				ReadFunc: func(didID string, opts ...vdriapi.ResolveOpts) (*did.Doc, error) {
+					*reads++
+
+					return read(didID)
+				}}, nil
+		}
+
+		return v
+	}
+
+	found := func(didID string) (*did.Doc, error) {
+		return &did.Doc{ID: didID}, nil
+	}
+
+	t.Run("test cache disabled", func(t *testing.T) {
+		reads := 0
+		v := newCachedVDRI(found, &reads)
+
+		for i := 0; i < 2; i++ {
+			_, err := v.Read("did:trustbloc:testnet:123")
+			require.NoError(t, err)
+		}
+
+		require.Equal(t, 2, reads)
+		require.Equal(t, CacheStats{}, v.CacheStats())
+	})
+
+	t.Run("test cached result expires", func(t *testing.T) {
+		reads := 0
+		v := newCachedVDRI(found, &reads, WithCache(time.Minute, 0, 0))
+
+		now := time.Now()
+		v.cache.now = func() time.Time { return now }
+
+		result, err := v.Resolve("did:trustbloc:testnet:123")
+		require.NoError(t, err)
+		require.False(t, result.ResolverMetadata.Cached)
+
+		result, err = v.Resolve("did:trustbloc:testnet:123")
+		require.NoError(t, err)
+		require.True(t, result.ResolverMetadata.Cached)
+		require.Equal(t, "did:trustbloc:testnet:123", result.DIDDocument.ID)
+		require.Equal(t, 1, reads)
+
+		now = now.Add(time.Minute)
+
+		_, err = v.Read("did:trustbloc:testnet:123")
+		require.NoError(t, err)
+		require.Equal(t, 2, reads)
+		require.Equal(t, CacheStats{Hits: 1, Misses: 2, Entries: 1}, v.CacheStats())
+	})
+
+	t.Run("test no cache option", func(t *testing.T) {
+		reads := 0
+		v := newCachedVDRI(found, &reads, WithCache(time.Minute, 0, 0))
+
+		_, err := v.Read("did:trustbloc:testnet:123")
+		require.NoError(t, err)
+
+		result, err := v.Resolve("did:trustbloc:testnet:123", vdriapi.WithNoCache(true))
+		require.NoError(t, err)
+		require.False(t, result.ResolverMetadata.Cached)
+
+		_, err = v.Read("did:trustbloc:testnet:123", vdriapi.WithVersionID("1"))
+		require.True(t, errors.Is(err, ErrVersionNotSupported))
+		require.Equal(t, 2, reads)
+
+		_, err = v.Read("did:trustbloc:testnet:123")
+		require.NoError(t, err)
+		require.Equal(t, 2, reads)
+	})
+
+	t.Run("test not found", func(t *testing.T) {
+		reads := 0
+		notFound := func(didID string) (*did.Doc, error) {
+			return nil, vdriapi.ErrNotFound
+		}
+
+		v := newCachedVDRI(notFound, &reads, WithCache(time.Minute, time.Second, 0))
+
+		now := time.Now()
+		v.cache.now = func() time.Time { return now }
+
+		for i := 0; i < 2; i++ {
+			_, err := v.Read("did:trustbloc:testnet:123")
+			require.True(t, errors.Is(err, vdriapi.ErrNotFound))
+		}
+
+		require.Equal(t, 1, reads)
+
+		now = now.Add(time.Second)
+
+		_, err := v.Read("did:trustbloc:testnet:123")
+		require.Error(t, err)
+		require.Equal(t, 2, reads)
+
+		v = newCachedVDRI(notFound, &reads, WithCache(time.Minute, 0, 0))
+
+		for i := 0; i < 2; i++ {
+			_, err := v.Read("did:trustbloc:testnet:123")
+			require.Error(t, err)
+		}
+
+		require.Equal(t, 4, reads)
+	})
+
+	t.Run("test other errors are not cached", func(t *testing.T) {
+		reads := 0
+		v := newCachedVDRI(func(didID string) (*did.Doc, error) {
+			return nil, errors.New("read error")
+		}, &reads, WithCache(time.Minute, time.Minute, 0))
+
+		for i := 0; i < 2; i++ {
+			_, err := v.Read("did:trustbloc:testnet:123")
+			require.Error(t, err)
+		}
+
+		require.Equal(t, 2, reads)
+	})
+
+	t.Run("test least recently used entry is evicted", func(t *testing.T) {
+		reads := 0
+		v := newCachedVDRI(found, &reads, WithCache(time.Minute, 0, 2))
+
+		for _, didID := range []string{"did:trustbloc:testnet:1", "did:trustbloc:testnet:2",
+			"did:trustbloc:testnet:1", "did:trustbloc:testnet:3", "did:trustbloc:testnet:1", "did:trustbloc:testnet:2"} {
+			_, err := v.Read(didID)
+			require.NoError(t, err)
+		}
+
+		// did2 was evicted by did3
+		require.Equal(t, 4, reads)
+		require.Equal(t, CacheStats{Hits: 2, Misses: 4, Entries: 2}, v.CacheStats())
+	})
+}
+
+func TestVDRI_ReadWithContext(t *testing.T) {
+	t.Run("test cancelled context", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+
+		v := New()
+		v.endpointService = &mockendpoint.MockEndpointService{
+			GetEndpointsFunc: func(domain string) ([]*models.Endpoint, error) {
+				return []*models.Endpoint{{URL: "url"}}, nil
+			}}
+		v.getHTTPVDRI = func(ctx context.Context, url string) (v vdri, err error) {
+			return &mockvdri.MockVDRI{
+				ReadFunc: func(didID string, opts ...vdriapi.ResolveOpts) (*did.Doc, error) {
+					<-release
+
+					return nil, nil
+				}}, nil
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := v.ReadWithContext(ctx, "did:trustbloc:testnet:123")
+		require.Error(t, err)
+		require.True(t, errors.Is(err, context.DeadlineExceeded))
+	})
+
+	t.Run("test http resolver", func(t *testing.T) {
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "Bearer tk1", r.Header.Get("Authorization"))
+
+			switch r.URL.Path {
+			case "/identifiers/did:trustbloc:testnet:doc":
+				w.Header().Set("Content-type", didLDJson)
+				fmt.Fprint(w, `{"@context":"https://w3id.org/did/v1","id":"did:trustbloc:testnet:doc"}`)
+			case "/identifiers/did:trustbloc:testnet:resolution":
+				w.Header().Set("Content-type", didLDJson)
+				fmt.Fprint(w, `{"didDocument":{"@context":"https://w3id.org/did/v1",`+
+					`"id":"did:trustbloc:testnet:resolution"}}`)
+			case "/identifiers/did:trustbloc:testnet:deactivated":
+				w.WriteHeader(http.StatusGone)
+			case "/identifiers/did:trustbloc:testnet:failing":
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprint(w, "upstream [410]")
+			case "/identifiers/did:trustbloc:testnet:hanging":
+				<-r.Context().Done()
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer serv.Close()
+
+		v := New(WithResolverURL(serv.URL+"/identifiers"), WithAuthToken("tk1"))
+
+		doc, err := v.Read("did:trustbloc:testnet:doc")
+		require.NoError(t, err)
+		require.Equal(t, "did:trustbloc:testnet:doc", doc.ID)
+
+		doc, err = v.Read("did:trustbloc:testnet:resolution")
+		require.NoError(t, err)
+		require.Equal(t, "did:trustbloc:testnet:resolution", doc.ID)
+
+		_, err = v.Read("did:trustbloc:testnet:deactivated")
+		require.True(t, errors.Is(err, ErrDIDDeactivated))
+
+		_, err = v.Read("did:trustbloc:testnet:failing")
+		require.Error(t, err)
+		require.False(t, errors.Is(err, ErrDIDDeactivated))
+		require.False(t, isNotFound(err))
+		require.Contains(t, err.Error(), "unsupported response from DID resolver [500]")
+
+		_, err = v.Read("did:trustbloc:testnet:unknown")
+		require.Error(t, err)
+		require.True(t, isNotFound(err))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err = v.ReadWithContext(ctx, "did:trustbloc:testnet:hanging")
+		require.True(t, errors.Is(err, context.DeadlineExceeded))
+	})
+}
+
+func TestVDRI_ResolveVersion(t *testing.T) {
+	pubKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	computed, err := didclient.New().ComputeDID("testnet", didclient.WithPublicKey(&didclient.PublicKey{
+		ID: "key1", Type: didclient.JWSVerificationKey2020, Encoding: didclient.PublicKeyEncodingJwk,
+		KeyType: didclient.Ed25519KeyType, Value: pubKey, Usage: []string{didclient.KeyUsageGeneral}}),
+		didclient.WithPublicKey(&didclient.PublicKey{Encoding: didclient.PublicKeyEncodingJwk,
+			KeyType: didclient.Ed25519KeyType, Value: pubKey, Recovery: true}))
+	require.NoError(t, err)
+
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.FailNow(t, "a version of the did should not be resolved from the latest version")
+	}))
+	defer serv.Close()
+
+	t.Run("test version id", func(t *testing.T) {
+		v := New(WithCache(time.Minute, time.Minute, 0))
+
+		v.endpointService = &mockendpoint.MockEndpointService{
+			GetEndpointsFunc: func(domain string) (endpoints []*models.Endpoint, err error) {
+				return []*models.Endpoint{{URL: serv.URL}}, nil
+			}}
+
+		// not built from the initial state of the long-form did
+		doc, err := v.Read(computed.LongForm, vdriapi.WithVersionID("1"))
+		require.Error(t, err)
+		require.True(t, errors.Is(err, ErrVersionNotSupported))
+		require.Nil(t, doc)
+	})
+
+	t.Run("test version time", func(t *testing.T) {
+		v := New()
+
+		v.endpointService = &mockendpoint.MockEndpointService{
+			GetEndpointsFunc: func(domain string) (endpoints []*models.Endpoint, err error) {
+				return []*models.Endpoint{{URL: serv.URL}}, nil
+			}}
+
+		result, err := v.Resolve(computed.ShortForm, vdriapi.WithVersionTime(time.Now()))
+		require.Error(t, err)
+		require.True(t, errors.Is(err, ErrVersionNotSupported))
+		require.Nil(t, result)
+	})
+
+	t.Run("test resolver url", func(t *testing.T) {
+		_, err := New(WithResolverURL(serv.URL+"/identifiers")).Read(computed.ShortForm,
+			vdriapi.WithVersionID(20))
+		require.Error(t, err)
+		require.True(t, errors.Is(err, ErrVersionNotSupported))
+	})
+}
+
+func TestVDRI_Retry(t *testing.T) {
+	newServer := func(failures int) (*httptest.Server, *int32) {
+		var requests int32
+
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if int(atomic.AddInt32(&requests, 1)) <= failures {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+
+			w.Header().Set("Content-type", "application/did+ld+json")
+			_, err := w.Write([]byte(`{"@context":"https://w3id.org/did/v1","id":"did:trustbloc:testnet:123"}`))
+			require.NoError(t, err)
+		}))
+
+		return serv, &requests
+	}
+
+	withEndpoint := func(v *VDRI, url string) *VDRI {
+		v.endpointService = &mockendpoint.MockEndpointService{
+			GetEndpointsFunc: func(domain string) (endpoints []*models.Endpoint, err error) {
+				return []*models.Endpoint{{URL: url}}, nil
+			}}
+
+		return v
+	}
+
+	t.Run("test transient failure retried", func(t *testing.T) {
+		serv, requests := newServer(1)
+		defer serv.Close()
+
+		v := withEndpoint(New(WithRetry(2, time.Millisecond, time.Millisecond)), serv.URL)
+
+		doc, err := v.Read("did:trustbloc:testnet:123")
+		require.NoError(t, err)
+		require.Equal(t, "did:trustbloc:testnet:123", doc.ID)
+		require.EqualValues(t, 2, atomic.LoadInt32(requests))
+	})
+
+	t.Run("test failing endpoint skipped", func(t *testing.T) {
+		serv, requests := newServer(5)
+		defer serv.Close()
+
+		v := withEndpoint(New(WithCircuitBreaker(1, time.Hour)), serv.URL)
+
+		_, err := v.Read("did:trustbloc:testnet:123")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "[503]")
+
+		result, err := v.Read("did:trustbloc:testnet:123")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "circuit breaker is open")
+		require.Nil(t, result)
+		require.EqualValues(t, 1, atomic.LoadInt32(requests))
+	})
+}
+
+func TestVDRI_AutomaticBootstrapping(t *testing.T) {
+	stakeholderDID := "did:trustbloc:testnet:stakeholder"
+
+	privKey, pubKey, err := mockmodels.GenerateKey(stakeholderDID + "#key1")
+	require.NoError(t, err)
+
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-type", didLDJson)
+
+		switch r.URL.Path {
+		case "/sidetree/0.0.1/identifiers/" + stakeholderDID:
+			fmt.Fprintf(w, `{"@context":"https://w3id.org/did/v1","id":"%s","publicKey":[{"id":"#key1",`+
+				`"type":"Ed25519VerificationKey2018","controller":"%s","publicKeyBase58":"%s"}]}`,
+				stakeholderDID, stakeholderDID, base58.Encode(pubKey.JWK.Key.(ed25519.PublicKey)))
+		case "/sidetree/0.0.1/identifiers/did:trustbloc:testnet:123":
+			fmt.Fprint(w, `{"@context":"https://w3id.org/did/v1","id":"did:trustbloc:testnet:123"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer serv.Close()
+
+	stakeholder := mockmodels.DummyStakeholder("stakeholder.one", []string{serv.URL + "/sidetree/0.0.1"})
+	stakeholder.DID = stakeholderDID
+
+	stakeholderFile, err := mockmodels.SignStakeholder(stakeholder, privKey)
+	require.NoError(t, err)
+
+	stakeholderData, err := models.ParseStakeholder([]byte(stakeholderFile))
+	require.NoError(t, err)
+
+	didConfig, err := mockmodels.DIDConfiguration(stakeholderDID, "stakeholder.one", privKey)
+	require.NoError(t, err)
+
+	consortium := mockmodels.DummyConsortium("testnet", []models.StakeholderListElement{
+		{Domain: "stakeholder.one", DID: stakeholderDID, PublicKey: pubKey}})
+
+	v := New(WithAutomaticBootstrapping())
+	v.endpointService = v.newEndpointService(&mockconfig.MockConfigService{
+		GetConsortiumFunc: func(string, string) (*models.ConsortiumFileData, error) {
+			return &models.ConsortiumFileData{Config: consortium}, nil
+		},
+		GetStakeholderFunc: func(string, string) (*models.StakeholderFileData, error) {
+			return stakeholderData, nil
+		},
+		GetDIDConfigurationFunc: func(string) (*models.DIDConfiguration, error) {
+			return didConfig, nil
+		},
+	})
+
+	doc, err := v.Read("did:trustbloc:testnet:123")
+	require.NoError(t, err)
+	require.Equal(t, "did:trustbloc:testnet:123", doc.ID)
+}
+
 func TestVDRI_Close(t *testing.T) {
 	v := New()
 	require.NoError(t, v.Close())
@@ -238,7 +1130,11 @@ func TestOpts(t *testing.T) {
 	t.Run("test opts", func(t *testing.T) {
 		// test WithTLSConfig
 		var opts []Option
-		opts = append(opts, WithTLSConfig(&tls.Config{ServerName: "test"}), WithAuthToken("tk1"))
+		opts = append(opts, WithTLSConfig(&tls.Config{ServerName: "test"}), WithAuthToken("tk1"),
+			WithTimeout(time.Second), WithCache(time.Minute, time.Second, 10),
+			WithDomain("testnet"), WithWriteAuthToken("tk2"), WithRetry(2, time.Millisecond, time.Second),
+			WithCircuitBreaker(5, time.Minute), WithAutomaticBootstrapping(),
+			WithRevealValueStore(&mockstorage.MockStore{}))
 
 		v := &VDRI{}
 
@@ -249,9 +1145,50 @@ func TestOpts(t *testing.T) {
 
 		require.Equal(t, "test", v.tlsConfig.ServerName)
 		require.Equal(t, "tk1", v.authToken)
+		require.Equal(t, time.Second, v.timeout)
+		require.Equal(t, "testnet", v.domain)
+		require.Equal(t, "tk2", v.writeAuthToken)
+		require.Equal(t, time.Minute, v.cacheTTL)
+		require.Equal(t, time.Second, v.notFoundTTL)
+		require.Equal(t, 10, v.cacheMaxEntries)
+		require.Len(t, v.transportOpts, 2)
+		require.Len(t, v.configOpts, 2)
+		require.Len(t, v.clientOpts, 2)
+		require.True(t, v.bootstrap)
+		require.NotNil(t, v.revealValueStore)
+		require.NotNil(t, New(WithAutomaticBootstrapping()).endpointService)
 	})
 }
 
+// sidetreeDocument returns the did document a sidetree node resolves once the long-form did is anchored
+func sidetreeDocument(t *testing.T, longFormDID string) []byte {
+	parsed, err := didclient.ParseDID(longFormDID)
+	require.NoError(t, err)
+
+	parts := strings.Split(parsed.InitialState, ".")
+	require.Len(t, parts, 2)
+
+	createRequest, err := json.Marshal(&model.CreateRequest{Operation: model.OperationTypeCreate,
+		SuffixData: parts[0], Delta: parts[1]})
+	require.NoError(t, err)
+
+	op, err := operation.ParseCreateOperation(createRequest, protocol.Protocol{HashAlgorithmInMultiHashCode: sha2_256})
+	require.NoError(t, err)
+
+	internal, err := composer.ApplyPatches(make(document.Document), op.Delta.Patches)
+	require.NoError(t, err)
+
+	internal[document.IDProperty] = parsed.ShortForm()
+
+	result, err := didvalidator.New(nil).TransformDocument(internal)
+	require.NoError(t, err)
+
+	docBytes, err := result.Document.Bytes()
+	require.NoError(t, err)
+
+	return docBytes
+}
+
 //nolint:deadcode,unused
 func generateDIDDoc(id string) *did.Doc {
 	t := time.Unix(0, 0)
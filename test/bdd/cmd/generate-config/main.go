@@ -0,0 +1,160 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Generates the well-known-server config files for the discovery service in BDD tests.
+// Each config-data file of the given fixture folders is written as a JWS to the config folder:
+// the stakeholder files are signed with a key generated for the stakeholder, and the consortium files
+// list the public key of each member stakeholder and are signed by all of them.
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/square/go-jose"
+
+	"github.com/trustbloc/trustbloc-did-method/pkg/vdri/trustbloc/models"
+)
+
+const (
+	configDataDir = "config-data"
+	configDir     = "config"
+	fileMode      = 0644
+	dirMode       = 0755
+)
+
+func main() {
+	g := &generator{keys: map[string]ed25519.PrivateKey{}}
+
+	for _, folder := range os.Args[1:] {
+		if err := g.generate(folder); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to generate config for %s: %s\n", folder, err)
+			os.Exit(1)
+		}
+	}
+}
+
+// generator holds the signing key of each stakeholder domain, shared by all fixture folders
+type generator struct {
+	keys map[string]ed25519.PrivateKey
+}
+
+func (g *generator) generate(folder string) error {
+	files, err := ioutil.ReadDir(filepath.Join(folder, configDataDir))
+	if err != nil {
+		return err
+	}
+
+	if err = os.RemoveAll(filepath.Join(folder, configDir)); err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Join(folder, configDir), dirMode); err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		data, err := ioutil.ReadFile(filepath.Join(folder, configDataDir, f.Name())) // nolint: gosec
+		if err != nil {
+			return err
+		}
+
+		out, err := g.convert(strings.TrimSuffix(f.Name(), filepath.Ext(f.Name())), data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.Name(), err)
+		}
+
+		if err = ioutil.WriteFile(filepath.Join(folder, configDir, f.Name()), []byte(out), fileMode); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// convert converts the config file of the domain to a JWS
+func (g *generator) convert(domain string, data []byte) (string, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return "", err
+	}
+
+	if _, ok := fields["members"]; !ok {
+		return g.sign(domain, data)
+	}
+
+	var consortium models.Consortium
+	if err := json.Unmarshal(data, &consortium); err != nil {
+		return "", err
+	}
+
+	for i := range consortium.Members {
+		domain := consortium.Members[i].Domain
+		consortium.Members[i].PublicKey = &models.PublicKey{ID: domain,
+			JWK: &jose.JSONWebKey{Key: g.key(domain).Public(), Algorithm: string(jose.EdDSA)}}
+	}
+
+	out, err := json.Marshal(consortium)
+	if err != nil {
+		return "", err
+	}
+
+	// the consortium config is endorsed by all its members
+	keys := make([]jose.SigningKey, len(consortium.Members))
+	for i, member := range consortium.Members {
+		keys[i] = jose.SigningKey{Algorithm: jose.EdDSA,
+			Key: &jose.JSONWebKey{Key: g.key(member.Domain), KeyID: member.Domain}}
+	}
+
+	signer, err := jose.NewMultiSigner(keys, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return signWith(signer, out)
+}
+
+// sign signs the stakeholder config file of the domain with the stakeholder key
+func (g *generator) sign(domain string, data []byte) (string, error) {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.EdDSA,
+		Key: &jose.JSONWebKey{Key: g.key(domain), KeyID: domain}}, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return signWith(signer, data)
+}
+
+func signWith(signer jose.Signer, data []byte) (string, error) {
+	jws, err := signer.Sign(data)
+	if err != nil {
+		return "", err
+	}
+
+	return jws.FullSerialize(), nil
+}
+
+// key returns the signing key of the stakeholder domain, generating it on first use
+func (g *generator) key(domain string) ed25519.PrivateKey {
+	if key, ok := g.keys[domain]; ok {
+		return key
+	}
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+
+	g.keys[domain] = key
+
+	return key
+}
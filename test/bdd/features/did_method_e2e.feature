@@ -13,7 +13,10 @@ Feature: Using DID method REST API
     Given TrustBloc DID is created through registrar "http://localhost:9080/1.0/register?driverId=driver-did-method-rest" with key type "<keyType>" with signature suite "<signatureSuite>"
     Then Resolve created DID through resolver URL "http://localhost:8080/1.0/identifiers" and validate key type "<keyType>", signature suite "<signatureSuite>"
     Examples:
-      | keyType  |  signatureSuite             |
-      | Ed25519  |  JwsVerificationKey2020     |
-      | P256     |  JwsVerificationKey2020     |
-      | Ed25519  |  Ed25519VerificationKey2018 |
+      | keyType   | signatureSuite                    |
+      | Ed25519   | JwsVerificationKey2020            |
+      | P256      | JwsVerificationKey2020            |
+      | Ed25519   | Ed25519VerificationKey2018        |
+      | secp256k1 | JwsVerificationKey2020            |
+      | secp256k1 | EcdsaSecp256k1VerificationKey2019 |
+      | X25519    | X25519KeyAgreementKey2019         |
@@ -18,6 +18,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcutil/base58"
 	"github.com/cucumber/godog"
 	"github.com/google/uuid"
@@ -41,6 +42,8 @@ const (
 	serviceID    = "service"
 	// P256KeyType EC P-256 key type
 	P256KeyType = "P256"
+
+	x25519PublicKeySize = 32
 )
 
 // Steps is steps for VC BDD tests
@@ -68,13 +71,25 @@ func (e *Steps) createDIDBloc(url, keyType, signatureSuite string) error { //nol
 		return err
 	}
 
+	// X25519 keys can't be recovery keys
+	recoveryKeyType, recoveryPubKey := keyType, pubKey
+
+	if keyType == did.X25519KeyType {
+		recoveryKeyType = did.Ed25519KeyType
+
+		recoveryPubKey, err = getPublicKey(recoveryKeyType)
+		if err != nil {
+			return err
+		}
+	}
+
 	jobID := uuid.New().String()
 
 	reqBytes, err := json.Marshal(operation.RegisterDIDRequest{JobID: jobID, DIDDocument: operation.DIDDocument{
 		PublicKey: []*operation.PublicKey{{ID: pubKeyIndex1, Type: signatureSuite,
 			Value: base64.StdEncoding.EncodeToString(pubKey), Encoding: did.PublicKeyEncodingJwk, KeyType: keyType,
 			Usage: []string{did.KeyUsageGeneral}}, {ID: pubKeyIndex2, Type: did.JWSVerificationKey2020,
-			Value: base64.StdEncoding.EncodeToString(pubKey), KeyType: keyType,
+			Value: base64.StdEncoding.EncodeToString(recoveryPubKey), KeyType: recoveryKeyType,
 			Encoding: did.PublicKeyEncodingJwk, Recovery: true}},
 		Service: []*operation.Service{{ID: serviceID, Type: "type", ServiceEndpoint: "http://www.example.com/"}}}})
 	if err != nil {
@@ -190,6 +205,19 @@ func getPublicKey(keyType string) ([]byte, error) {
 		ecPubKeyBytes := elliptic.Marshal(ecPrivKey.PublicKey.Curve, ecPrivKey.PublicKey.X, ecPrivKey.PublicKey.Y)
 
 		pubKey = ecPubKeyBytes
+	case did.Secp256k1KeyType:
+		secp256k1PrivKey, err := btcec.NewPrivateKey(btcec.S256())
+		if err != nil {
+			return nil, err
+		}
+
+		pubKey = secp256k1PrivKey.PubKey().SerializeUncompressed()
+	case did.X25519KeyType:
+		pubKey = make([]byte, x25519PublicKeySize)
+
+		if _, err := rand.Read(pubKey); err != nil {
+			return nil, err
+		}
 	}
 
 	return pubKey, nil
@@ -205,17 +233,17 @@ func validatePublicKey(doc *ariesdid.Doc, keyType, signatureSuite string) error
 	switch keyType {
 	case did.Ed25519KeyType:
 		expectedJwkKeyType = "OKP"
-	case P256KeyType:
+	case P256KeyType, did.Secp256k1KeyType:
 		expectedJwkKeyType = "EC"
 	}
 
-	if signatureSuite == did.JWSVerificationKey2020 &&
+	if (signatureSuite == did.JWSVerificationKey2020 || signatureSuite == did.EcdsaSecp256k1VerificationKey2019) &&
 		expectedJwkKeyType != doc.PublicKey[0].JSONWebKey().Kty {
 		return fmt.Errorf("jwk key type : expected=%s actual=%s", expectedJwkKeyType,
 			doc.PublicKey[0].JSONWebKey().Kty)
 	}
 
-	if signatureSuite == did.Ed25519VerificationKey2018 &&
+	if (signatureSuite == did.Ed25519VerificationKey2018 || signatureSuite == did.X25519KeyAgreementKey2019) &&
 		doc.PublicKey[0].JSONWebKey() != nil {
 		return fmt.Errorf("jwk is not nil for %s", signatureSuite)
 	}